@@ -0,0 +1,29 @@
+package Go_ORM
+
+// Assignable 标记接口, 代表可以出现在 UPDATE 语句 SET 部分的东西
+// Column 代表从 Updater.Update 传入的实体里取值
+// Assignment 代表直接指定值
+type Assignable interface {
+	assign()
+}
+
+type Assignment struct {
+	column string
+	val    any
+}
+
+// Assign("Age", 18) --> `age` = ?
+func Assign(column string, val any) Assignment {
+	return Assignment{
+		column: column,
+		val:    val,
+	}
+}
+
+func (Assignment) assign() {
+
+}
+
+func (Column) assign() {
+
+}
@@ -0,0 +1,91 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"reflect"
+	"time"
+)
+
+const (
+	conventionCreateTime = "CreatedAt"
+	conventionUpdateTime = "UpdatedAt"
+
+	autoTimeMilli = "milli"
+)
+
+// DBWithClock 指定自动维护时间使用的时钟, 默认是 time.Now, 一般用于测试
+func DBWithClock(clock func() time.Time) DBOption {
+	return func(db *DB) {
+		db.clock = clock
+	}
+}
+
+func (c core) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+// parseAutoTime 字段用标签声明了自动时间, 或者叫 CreatedAt、UpdatedAt
+// 支持 time.Time、*time.Time 和整数类型, 整数默认是秒
+// 毫秒放不进 32 位的整数, 所以 =milli 只能用在 64 位的整数上
+// 用标签声明的时候类型不对会返回错误, 约定的字段类型不对就当成普通字段
+func (f *Field) parseAutoTime(name string, pair map[string]string) error {
+	createUnit, create := pair[tagAutoCreateTime]
+	updateUnit, update := pair[tagAutoUpdateTime]
+	tagged := create || update
+	if !tagged {
+		create = name == conventionCreateTime
+		update = name == conventionUpdateTime
+	}
+	if !create && !update {
+		return nil
+	}
+	unit, tag := createUnit, tagAutoCreateTime
+	if update {
+		unit, tag = updateUnit, tagAutoUpdateTime
+	}
+
+	isTime := f.typ == timeType || f.typ == reflect.PointerTo(timeType)
+	if !isTime && !isIntType(f.typ) {
+		if tagged {
+			return errs.NewErrInvalidTagContent(tag)
+		}
+		return nil
+	}
+	switch {
+	case unit == "":
+	case unit == autoTimeMilli && !isTime && f.typ.Bits() >= 64:
+		f.autoTimeMilli = true
+	default:
+		return errs.NewErrInvalidTagContent(tag + "=" + unit)
+	}
+	f.autoCreateTime = create
+	f.autoUpdateTime = update
+	return nil
+}
+
+func isIntType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// autoTimeValue 把 now 转换成字段的类型
+func (f *Field) autoTimeValue(now time.Time) reflect.Value {
+	switch {
+	case f.typ == timeType:
+		return reflect.ValueOf(now)
+	case f.typ.Kind() == reflect.Pointer:
+		return reflect.ValueOf(&now)
+	case f.autoTimeMilli:
+		return reflect.ValueOf(now.UnixMilli()).Convert(f.typ)
+	default:
+		return reflect.ValueOf(now.Unix()).Convert(f.typ)
+	}
+}
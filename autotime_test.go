@@ -0,0 +1,243 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestField_parseAutoTime(t *testing.T) {
+	testCases := []struct {
+		name   string
+		entity any
+
+		wantCreate bool
+		wantUpdate bool
+		wantMilli  bool
+		wantErr    error
+	}{
+		{
+			name: "convention create",
+			entity: func() any {
+				type User struct {
+					CreatedAt time.Time
+				}
+				return &User{}
+			}(),
+			wantCreate: true,
+		},
+		{
+			name: "convention update",
+			entity: func() any {
+				type User struct {
+					UpdatedAt *time.Time
+				}
+				return &User{}
+			}(),
+			wantUpdate: true,
+		},
+		{
+			name: "convention unsupported type",
+			entity: func() any {
+				type User struct {
+					CreatedAt string
+				}
+				return &User{}
+			}(),
+		},
+		{
+			name: "tag milli",
+			entity: func() any {
+				type User struct {
+					Ctime int64 `orm:"autoCreateTime=milli"`
+				}
+				return &User{}
+			}(),
+			wantCreate: true,
+			wantMilli:  true,
+		},
+		{
+			// 毫秒会溢出 32 位整数
+			name: "tag milli int32",
+			entity: func() any {
+				type User struct {
+					Ctime int32 `orm:"autoCreateTime=milli"`
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("autoCreateTime=milli"),
+		},
+		{
+			name: "tag milli uint32",
+			entity: func() any {
+				type User struct {
+					Utime uint32 `orm:"autoUpdateTime=milli"`
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("autoUpdateTime=milli"),
+		},
+		{
+			name: "tag seconds",
+			entity: func() any {
+				type User struct {
+					Utime uint32 `orm:"autoUpdateTime"`
+				}
+				return &User{}
+			}(),
+			wantUpdate: true,
+		},
+		{
+			// 标签的优先级更高
+			name: "tag overrides convention",
+			entity: func() any {
+				type User struct {
+					CreatedAt int64 `orm:"autoUpdateTime"`
+				}
+				return &User{}
+			}(),
+			wantUpdate: true,
+		},
+		{
+			name: "tag unsupported type",
+			entity: func() any {
+				type User struct {
+					Ctime string `orm:"autoCreateTime"`
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("autoCreateTime"),
+		},
+		{
+			name: "milli on time",
+			entity: func() any {
+				type User struct {
+					Utime time.Time `orm:"autoUpdateTime=milli"`
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("autoUpdateTime=milli"),
+		},
+		{
+			name: "invalid unit",
+			entity: func() any {
+				type User struct {
+					Utime int64 `orm:"autoUpdateTime=nano"`
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("autoUpdateTime=nano"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewRegistry().Register(tc.entity)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			fd := m.fields[0]
+			assert.Equal(t, tc.wantCreate, fd.IsAutoCreateTime())
+			assert.Equal(t, tc.wantUpdate, fd.IsAutoUpdateTime())
+			assert.Equal(t, tc.wantMilli, fd.autoTimeMilli)
+		})
+	}
+}
+
+type AutoTimeModel struct {
+	Id        int64
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	Ctime     int64 `orm:"autoCreateTime"`
+	Utime     int64 `orm:"autoUpdateTime=milli"`
+}
+
+func TestAutoTime_Build(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	db := MustNewDB(DBWithClock(func() time.Time {
+		return now
+	}))
+
+	// 已经设置了的值不会被覆盖
+	am := &AutoTimeModel{Id: 1, Ctime: before.Unix()}
+	q, err := NewInserter[AutoTimeModel](db).Values(am).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "INSERT INTO `auto_time_model`(`id`,`created_at`,`updated_at`,`ctime`,`utime`) VALUES (?,?,?,?,?);",
+		Args: []any{int64(1), now, &now, before.Unix(), now.UnixMilli()},
+	}, q)
+	assert.Equal(t, &AutoTimeModel{Id: 1, CreatedAt: now, UpdatedAt: &now, Ctime: before.Unix(), Utime: now.UnixMilli()}, am)
+
+	testCases := []struct {
+		name    string
+		updater *Updater[AutoTimeModel]
+
+		wantQuery *Query
+	}{
+		{
+			name:    "append update time",
+			updater: NewUpdater[AutoTimeModel](db).Set(Assign("Ctime", 1)).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `auto_time_model` SET `ctime`=?,`updated_at`=?,`utime`=? WHERE `id` = ?;",
+				Args: []any{1, &now, now.UnixMilli(), 1},
+			},
+		},
+		{
+			name: "column uses now",
+			updater: NewUpdater[AutoTimeModel](db).
+				Update(&AutoTimeModel{Utime: before.UnixMilli()}).Set(C("Utime")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `auto_time_model` SET `utime`=?,`updated_at`=?;",
+				Args: []any{now.UnixMilli(), &now},
+			},
+		},
+		{
+			name:    "explicit assignment",
+			updater: NewUpdater[AutoTimeModel](db).Set(Assign("Utime", int64(0)), Assign("UpdatedAt", nil)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `auto_time_model` SET `utime`=?,`updated_at`=?;",
+				Args: []any{int64(0), nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.updater.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestAutoTime_Update(t *testing.T) {
+	type AutoTimeUser struct {
+		Id        int64
+		Name      string
+		CreatedAt int64
+		UpdatedAt int64
+	}
+	created := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	now := created
+	db := newSQLiteDB(t, DBWithClock(func() time.Time {
+		return now
+	}))
+	_, err := db.db.Exec("CREATE TABLE auto_time_user(id INTEGER PRIMARY KEY, name TEXT, created_at INTEGER, updated_at INTEGER)")
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = NewInserter[AutoTimeUser](db).Values(&AutoTimeUser{Id: 1, Name: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+
+	// Update 不会把创建时间改成零值
+	now = created.Add(time.Hour)
+	_, err = Update[AutoTimeUser](ctx, db, &AutoTimeUser{Id: 1, Name: "Jerry"})
+	require.NoError(t, err)
+	u, err := FindByID[AutoTimeUser](ctx, db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &AutoTimeUser{Id: 1, Name: "Jerry", CreatedAt: created.Unix(), UpdatedAt: now.Unix()}, u)
+}
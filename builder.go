@@ -0,0 +1,128 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"strings"
+)
+
+// builder 是 Selector、Deleter、Updater 公共的 SQL 构造部分
+type builder struct {
+	core
+	sb    *strings.Builder
+	args  []any
+	model *Model
+	// ctx 由 Get、GetMulti 和 Exec 设置, 给 TableNameResolver 使用
+	// 直接调用 Build 的时候是 nil
+	ctx context.Context
+}
+
+// reset 每次 Build 之前都要重置, 不然重复调用 Build 会把 SQL 拼接在一起
+func (b *builder) reset() {
+	b.sb = &strings.Builder{}
+	b.args = nil
+}
+
+func (b *builder) buildWhere(where []Predicate) error {
+	if len(where) == 0 {
+		return nil
+	}
+	b.sb.WriteString(" WHERE ")
+	p := where[0]
+	for i := 1; i < len(where); i++ {
+		p = p.And(where[i])
+	}
+	// p 本身就是一个Expression
+	return b.buildExpression(p)
+}
+
+func (b *builder) buildExpression(expr Expression) error {
+	switch exp := expr.(type) {
+	case nil:
+	case Predicate:
+		// 在这里处理 p
+		// p.left 构建好
+		// p.op 构建好
+		// p.right 构建好
+		_, ok := exp.left.(Predicate)
+		if ok {
+			b.sb.WriteByte('(')
+		}
+		if err := b.buildExpression(exp.left); err != nil {
+			return err
+		}
+		if ok {
+			b.sb.WriteByte(')')
+		}
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(exp.op.String())
+		b.sb.WriteByte(' ')
+		_, ok = exp.right.(Predicate)
+		if ok {
+			b.sb.WriteByte('(')
+		}
+		if err := b.buildExpression(exp.right); err != nil {
+			return err
+		}
+		if ok {
+			b.sb.WriteByte(')')
+		}
+	case Column:
+		if err := b.buildColumn(exp.name); err != nil {
+			return err
+		}
+	case value:
+		b.sb.WriteByte('?')
+		b.addArg(exp.val)
+	default:
+		return errs.NewErrUnsupportedExpression(expr)
+	}
+	return nil
+}
+
+// buildColumn 把字段名转换成列名写进去
+func (b *builder) buildColumn(name string) error {
+	fd, ok := b.model.fileMap[name]
+	// 字段不对或者 列不对
+	if !ok {
+		return errs.NewErrUnknownField(name)
+	}
+	b.quote(fd.colName)
+	return nil
+}
+
+// buildTable 用户指定了表名就直接使用, 否则使用反引号括起来的模型表名
+// 模型设置了 TableNameResolver 的时候, 模型表名由它决定, entity 会传给它
+func (b *builder) buildTable(table string, entity any) error {
+	if table != "" {
+		b.sb.WriteString(table)
+		return nil
+	}
+	name, err := b.model.resolveTableName(b.context(), entity)
+	if err != nil {
+		return err
+	}
+	b.quote(name)
+	return nil
+}
+
+// context 直接调用 Build 的时候没有 ctx, 就用 context.Background()
+func (b *builder) context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+func (b *builder) quote(name string) {
+	b.sb.WriteByte('`')
+	b.sb.WriteString(name)
+	b.sb.WriteByte('`')
+}
+
+func (b *builder) addArg(val any) {
+	if b.args == nil {
+		b.args = make([]any, 0, 8)
+	}
+	b.args = append(b.args, val)
+}
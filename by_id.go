@@ -0,0 +1,112 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+// FindByID 根据主键查询, 联合主键按照字段定义的顺序传入
+func FindByID[T any](ctx context.Context, sess Session, ids ...any) (*T, error) {
+	where, err := primaryKeyWhere[T](sess, ids)
+	if err != nil {
+		return nil, err
+	}
+	return NewSelector[T](sess).Where(where...).Get(ctx)
+}
+
+// DeleteByID 根据主键删除, AfterDelete 的接收者是只设置了主键的实体
+// 分片的模型如果主键不是分片键, 会落到多个分片上, 返回 ErrShardingMultipleWriteTargets
+func DeleteByID[T any](ctx context.Context, sess Session, ids ...any) (sql.Result, error) {
+	where, err := primaryKeyWhere[T](sess, ids)
+	if err != nil {
+		return nil, err
+	}
+	m, err := sess.getCore().r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	entity := new(T)
+	val := reflect.ValueOf(entity).Elem()
+	for i, pk := range m.primaryKeys {
+		setPrimaryKey(val.FieldByIndex(m.fileMap[pk].index), ids[i])
+	}
+	return NewDeleter[T](sess).Delete(entity).Where(where...).Exec(ctx)
+}
+
+// setPrimaryKey 数字之间可以转换, 例如字段是 int64, 传入的是 int
+// 其它类型对不上的时候保持零值
+func setPrimaryKey(fv reflect.Value, id any) {
+	iv := reflect.ValueOf(id)
+	if !iv.IsValid() {
+		return
+	}
+	if iv.Type().AssignableTo(fv.Type()) {
+		fv.Set(iv)
+		return
+	}
+	if isNumberKind(iv.Kind()) && isNumberKind(fv.Kind()) {
+		fv.Set(iv.Convert(fv.Type()))
+	}
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// Update 用实体的主键作为条件, 更新其它所有的列
+// 分片的模型会把分片键也加到条件里面, 分片键本身不会被更新
+// 自动创建时间只在插入的时候设置, 也不会被更新
+func Update[T any](ctx context.Context, sess Session, entity *T) (sql.Result, error) {
+	m, err := sess.getCore().r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	where, err := entityWhere(m, reflect.ValueOf(entity).Elem())
+	if err != nil {
+		return nil, err
+	}
+	assigns := make([]Assignable, 0, len(m.fields))
+	for _, fd := range m.fields {
+		if fd.primaryKey || fd.autoCreateTime || (m.sharding != nil && fd.name == m.sharding.key) {
+			continue
+		}
+		assigns = append(assigns, C(fd.name))
+	}
+	return NewUpdater[T](sess).Update(entity).Set(assigns...).Where(where...).Exec(ctx)
+}
+
+// entityWhere 用实体的主键作为条件, 分片的模型还会带上分片键
+func entityWhere(m *Model, val reflect.Value) ([]Predicate, error) {
+	if len(m.primaryKeys) == 0 {
+		return nil, errs.ErrNoPrimaryKey
+	}
+	res := make([]Predicate, 0, len(m.primaryKeys)+1)
+	for _, pk := range m.primaryKeys {
+		res = append(res, C(pk).Eq(val.FieldByIndex(m.fileMap[pk].index).Interface()))
+	}
+	if m.sharding != nil && !m.fileMap[m.sharding.key].primaryKey {
+		key := m.sharding.key
+		res = append(res, C(key).Eq(val.FieldByIndex(m.fileMap[key].index).Interface()))
+	}
+	return res, nil
+}
+
+func primaryKeyWhere[T any](sess Session, ids []any) ([]Predicate, error) {
+	m, err := sess.getCore().r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	if len(m.primaryKeys) == 0 {
+		return nil, errs.ErrNoPrimaryKey
+	}
+	if len(m.primaryKeys) != len(ids) {
+		return nil, errs.NewErrPrimaryKeyCount(len(m.primaryKeys), len(ids))
+	}
+	res := make([]Predicate, 0, len(ids))
+	for i, pk := range m.primaryKeys {
+		res = append(res, C(pk).Eq(ids[i]))
+	}
+	return res, nil
+}
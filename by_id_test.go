@@ -0,0 +1,112 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestByID(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	_, err := NewInserter[TestModel](db).Values(
+		&TestModel{Id: 1, Age: 18, FirstName: "Tom"},
+		&TestModel{Id: 2, Age: 19, FirstName: "Jerry"},
+	).Exec(ctx)
+	require.NoError(t, err)
+
+	tm, err := FindByID[TestModel](ctx, db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, Age: 18, FirstName: "Tom"}, tm)
+
+	tm.Age = 20
+	tm.LastName = &sql.NullString{String: "Cat", Valid: true}
+	res, err := Update[TestModel](ctx, db, tm)
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	tm, err = FindByID[TestModel](ctx, db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, Age: 20, FirstName: "Tom",
+		LastName: &sql.NullString{String: "Cat", Valid: true}}, tm)
+	// 其它行不受影响
+	tm, err = FindByID[TestModel](ctx, db, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int8(19), tm.Age)
+
+	res, err = DeleteByID[TestModel](ctx, db, 1)
+	require.NoError(t, err)
+	affected, err = res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	_, err = FindByID[TestModel](ctx, db, 1)
+	assert.Equal(t, ErrNoRows, err)
+
+	_, err = FindByID[TestModel](ctx, db, 1, 2)
+	assert.Equal(t, errs.NewErrPrimaryKeyCount(1, 2), err)
+}
+
+type CompositeKeyModel struct {
+	UserId  int64 `orm:"pk"`
+	OrderId int64 `orm:"pk"`
+	Amount  int64
+}
+
+type NoKeyModel struct {
+	Name string
+}
+
+func TestByID_Build(t *testing.T) {
+	db, err := NewDB()
+	require.NoError(t, err)
+
+	where, err := primaryKeyWhere[CompositeKeyModel](db, []any{1, 2})
+	require.NoError(t, err)
+	q, err := NewDeleter[CompositeKeyModel](db).Where(where...).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "DELETE FROM `composite_key_model` WHERE (`user_id` = ?) AND (`order_id` = ?);",
+		Args: []any{1, 2},
+	}, q)
+
+	_, err = primaryKeyWhere[CompositeKeyModel](db, []any{1})
+	assert.Equal(t, errs.NewErrPrimaryKeyCount(2, 1), err)
+	_, err = primaryKeyWhere[NoKeyModel](db, []any{1})
+	assert.Equal(t, errs.ErrNoPrimaryKey, err)
+	_, err = Update[NoKeyModel](context.Background(), db, &NoKeyModel{})
+	assert.Equal(t, errs.ErrNoPrimaryKey, err)
+}
+
+type EmbeddedModel struct {
+	BaseModel
+	Addr Address `orm:"embedded,prefix=addr_"`
+}
+
+func TestByID_Embedded(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	_, err := db.db.ExecContext(ctx, "CREATE TABLE embedded_model(id INTEGER PRIMARY KEY, created_at INTEGER, updated_at INTEGER, addr_city TEXT, addr_street TEXT)")
+	require.NoError(t, err)
+
+	em := &EmbeddedModel{
+		BaseModel: BaseModel{Id: 1, CreatedAt: 100},
+		Addr:      Address{City: "Shanghai", Street: "Nanjing Road"},
+	}
+	_, err = NewInserter[EmbeddedModel](db).Values(em).Exec(ctx)
+	require.NoError(t, err)
+	res, err := FindByID[EmbeddedModel](ctx, db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, em, res)
+
+	em.UpdatedAt = 200
+	em.Addr.City = "Beijing"
+	_, err = Update[EmbeddedModel](ctx, db, em)
+	require.NoError(t, err)
+	res, err = FindByID[EmbeddedModel](ctx, db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, em, res)
+}
@@ -1,14 +1,38 @@
 package Go_ORM
 
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+)
+
 type DBOption func(db *DB)
 
 type DB struct {
-	r *registry
+	core
+	// db 主库
+	db       *sql.DB
+	replicas *replicaGroup
 }
 
-func NewDB(opts ...DBOption) (*DB, error) {
+// Open 创建一个 DB 实例
+// 默认情况下, 该 DB 使用 MySQL 方言
+func Open(driver string, dsn string, opts ...DBOption) (*DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return OpenDB(db, opts...)
+}
+
+// OpenDB 用户已经有了 *sql.DB, 可以直接用这个方法
+func OpenDB(db *sql.DB, opts ...DBOption) (*DB, error) {
 	res := &DB{
-		r: NewRegistry(),
+		core: core{
+			r:       NewRegistry(),
+			dialect: MySQL,
+		},
+		db: db,
 	}
 	for _, opt := range opts {
 		opt(res)
@@ -16,6 +40,11 @@ func NewDB(opts ...DBOption) (*DB, error) {
 	return res, nil
 }
 
+// NewDB 不持有任何连接, 只能用来构造 SQL
+func NewDB(opts ...DBOption) (*DB, error) {
+	return OpenDB(nil, opts...)
+}
+
 func MustNewDB(opts ...DBOption) *DB {
 	res, err := NewDB(opts...)
 	if err != nil {
@@ -23,3 +52,146 @@ func MustNewDB(opts ...DBOption) *DB {
 	}
 	return res
 }
+
+// DBWithDialect 指定方言, 默认是 MySQL
+func DBWithDialect(dialect Dialect) DBOption {
+	return func(db *DB) {
+		db.dialect = dialect
+	}
+}
+
+// Register 注册模型, 需要传入 ModelOpt 的时候要在使用模型之前调用
+func (db *DB) Register(entity any, opts ...ModelOpt) (*Model, error) {
+	return db.r.Register(entity, opts...)
+}
+
+// RegisterAll 注册多个模型, 返回的错误包含了所有失败的模型
+// 配合 DBWithStrictRegistry 使用, 在启动的时候就能发现模型定义的错误
+func (db *DB) RegisterAll(entities ...any) error {
+	return db.r.RegisterAll(entities...)
+}
+
+// Models 已经注册的模型, 按照表名排序
+func (db *DB) Models() []*Model {
+	return db.r.Models()
+}
+
+// DBWithStrictRegistry 没有注册过的模型不会在第一次使用的时候自动注册, 而是返回错误
+func DBWithStrictRegistry() DBOption {
+	return func(db *DB) {
+		db.r.strict = true
+	}
+}
+
+// BeginTx 开启事务
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if db.db == nil {
+		return nil, errs.ErrNoConnection
+	}
+	tx, err := db.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx, db: db}, nil
+}
+
+// DoTx 在事务里面执行 fn
+// fn 返回 nil 就提交, 返回 error 就回滚, 发生 panic 就先回滚再把 panic 继续抛出去
+// 回滚失败的时候, 返回的 error 同时包含业务错误和回滚错误
+// 如果 ctx 里面已经有这个 DB 的事务了, 就会变成嵌套事务, 此时 opts 不起作用
+func (db *DB) DoTx(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) error {
+	if tx, ok := db.txFromContext(ctx); ok {
+		return tx.DoTx(ctx, fn)
+	}
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return doTx(ctx, tx, fn)
+}
+
+// Close 会同时关闭主库、从库和分库
+// NewDB 创建的 DB 没有主库, 关闭的时候会跳过
+func (db *DB) Close() error {
+	var err error
+	if db.db != nil {
+		err = db.db.Close()
+	}
+	if rErr := db.replicas.close(); err == nil {
+		err = rErr
+	}
+	for _, sdb := range db.shardingDBs {
+		if sErr := sdb.Close(); err == nil {
+			err = sErr
+		}
+	}
+	return err
+}
+
+func (db *DB) getCore() core {
+	return db.core
+}
+
+// txFromContext 只有 ctx 里面的事务是自己开启的, 才会加入这个事务
+func (db *DB) txFromContext(ctx context.Context) (*Tx, bool) {
+	tx, ok := TxFromContext(ctx)
+	if !ok || tx.db != db {
+		return nil, false
+	}
+	return tx, true
+}
+
+func (db *DB) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx, ok := db.txFromContext(ctx); ok {
+		return tx.queryContext(ctx, query, args...)
+	}
+	if !IsUseMaster(ctx) {
+		if replica := db.replica(ctx); replica != nil {
+			return replica.QueryContext(ctx, query, args...)
+		}
+	}
+	if db.db == nil {
+		return nil, errs.ErrNoConnection
+	}
+	return db.db.QueryContext(ctx, query, args...)
+}
+
+type replicaKey struct{}
+
+// pinnedReplica 记录是哪个 DB 选的从库, 别的 DB 不能使用
+type pinnedReplica struct {
+	db      *DB
+	replica *sql.DB
+}
+
+// pinReplica 提前选好从库放进 ctx, 之后用这个 ctx 的查询都走这个从库, 不会再次轮询
+// 事务里面和 UseMaster 的查询不会走从库, 所以不需要选
+func (db *DB) pinReplica(ctx context.Context) context.Context {
+	if _, ok := db.txFromContext(ctx); ok || IsUseMaster(ctx) {
+		return ctx
+	}
+	if p, ok := ctx.Value(replicaKey{}).(pinnedReplica); ok && p.db == db {
+		return ctx
+	}
+	return context.WithValue(ctx, replicaKey{}, pinnedReplica{db: db, replica: db.replicas.next()})
+}
+
+// replica ctx 里面选好了从库就用它, 否则轮询
+func (db *DB) replica(ctx context.Context) *sql.DB {
+	if p, ok := ctx.Value(replicaKey{}).(pinnedReplica); ok && p.db == db {
+		return p.replica
+	}
+	return db.replicas.next()
+}
+
+func (db *DB) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx, ok := db.txFromContext(ctx); ok {
+		return tx.execContext(ctx, query, args...)
+	}
+	if db.db == nil {
+		return nil, errs.ErrNoConnection
+	}
+	return db.db.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,53 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+// newSQLiteDB 每个测试一个独立的数据库文件, 并且建好 test_model 表
+func newSQLiteDB(t *testing.T, opts ...DBOption) *DB {
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db")
+	opts = append([]DBOption{DBWithDialect(SQLite)}, opts...)
+	db, err := Open("sqlite3", dsn, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	_, err = db.db.ExecContext(context.Background(), `
+CREATE TABLE IF NOT EXISTS test_model(
+    id INTEGER PRIMARY KEY,
+    first_name TEXT NOT NULL,
+    age INTEGER,
+    last_name TEXT
+)
+`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestNewDB_NoConnection(t *testing.T) {
+	db := MustNewDB()
+	ctx := context.Background()
+
+	_, err := db.BeginTx(ctx, nil)
+	assert.Equal(t, errs.ErrNoConnection, err)
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		return nil
+	}, nil)
+	assert.Equal(t, errs.ErrNoConnection, err)
+
+	_, err = NewSelector[TestModel](db).Get(ctx)
+	assert.Equal(t, errs.ErrNoConnection, err)
+	_, err = NewSelector[TestModel](db).GetMulti(ctx)
+	assert.Equal(t, errs.ErrNoConnection, err)
+	_, err = NewDeleter[TestModel](db).Exec(ctx)
+	assert.Equal(t, errs.ErrNoConnection, err)
+
+	assert.NoError(t, db.Close())
+}
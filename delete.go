@@ -0,0 +1,116 @@
+package Go_ORM
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+type Deleter[T any] struct {
+	builder
+	table string
+	val   *T
+	where []Predicate
+
+	hints []string
+
+	sess Session
+}
+
+func NewDeleter[T any](sess Session) *Deleter[T] {
+	return &Deleter[T]{
+		builder: builder{
+			core: sess.getCore(),
+		},
+		sess: sess,
+	}
+}
+
+func (d *Deleter[T]) Build() (*Query, error) {
+	d.reset()
+	var err error
+	d.model, err = d.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	where := d.where
+	// 只传了实体的时候按照主键删除, 不能变成删除整张表
+	if len(where) == 0 && d.val != nil {
+		where, err = entityWhere(d.model, reflect.ValueOf(d.val).Elem())
+		if err != nil {
+			return nil, err
+		}
+	}
+	table, db, err := d.shardingWriteTarget(d.table, func(c *shardingConfig) ([]Dst, error) {
+		return c.routeWhere(where)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sb := d.sb
+	sb.WriteString("DELETE")
+	if err = d.dialect.buildOptimizerHints(&d.builder, d.hints); err != nil {
+		return nil, err
+	}
+	sb.WriteString(" FROM ")
+	entity := d.val
+	if entity == nil {
+		entity = new(T)
+	}
+	if err = d.buildTable(table, entity); err != nil {
+		return nil, err
+	}
+
+	if err = d.buildWhere(where); err != nil {
+		return nil, err
+	}
+
+	sb.WriteByte(';')
+	return &Query{
+		SQL:  sb.String(),
+		Args: d.args,
+		DB:   db,
+	}, nil
+}
+
+func (d *Deleter[T]) From(table string) *Deleter[T] {
+	d.table = table
+	return d
+}
+
+// Delete 指定被删除的实体, 执行成功之后会在它上面调用 AfterDelete
+// 没有调用 Where 的时候用实体的主键作为条件, 模型没有主键会返回 ErrNoPrimaryKey
+func (d *Deleter[T]) Delete(val *T) *Deleter[T] {
+	d.val = val
+	return d
+}
+
+func (d *Deleter[T]) Where(ps ...Predicate) *Deleter[T] {
+	d.where = ps
+	return d
+}
+
+// Hints 优化器提示
+// 注意 MySQL 单表 DELETE 不支持索引提示, 所以 Deleter 没有 UseIndex 之类的方法
+func (d *Deleter[T]) Hints(hints ...string) *Deleter[T] {
+	d.hints = append(d.hints, hints...)
+	return d
+}
+
+func (d *Deleter[T]) Exec(ctx context.Context) (sql.Result, error) {
+	d.ctx = ctx
+	q, err := d.Build()
+	if err != nil {
+		return nil, err
+	}
+	res, err := exec(ctx, d.sess, &QueryContext{
+		Type:    OpDelete,
+		Builder: d,
+		Query:   q,
+		Model:   d.model,
+	})
+	if err != nil || !d.model.hooks.afterDelete || d.val == nil {
+		return res, err
+	}
+	return res, any(d.val).(AfterDeleter).AfterDelete(ctx, d.sess)
+}
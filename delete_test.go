@@ -0,0 +1,114 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDeleter_Build(t *testing.T) {
+	db, err := NewDB()
+	require.NoError(t, err)
+	sqliteDB, err := NewDB(DBWithDialect(SQLite))
+	require.NoError(t, err)
+	testCases := []struct {
+		name    string
+		builder QueryBuilder
+
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "no where",
+			builder: NewDeleter[TestModel](db),
+			wantQuery: &Query{
+				SQL: "DELETE FROM `test_model`;",
+			},
+		},
+
+		{
+			name:    "from",
+			builder: NewDeleter[TestModel](db).From("`test_db`.`test_model`"),
+			wantQuery: &Query{
+				SQL: "DELETE FROM `test_db`.`test_model`;",
+			},
+		},
+
+		{
+			name:    "where",
+			builder: NewDeleter[TestModel](db).Where(C("Age").Eq(18).And(C("FirstName").Eq("Tom"))),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `test_model` WHERE (`age` = ?) AND (`first_name` = ?);",
+				Args: []any{18, "Tom"},
+			},
+		},
+
+		{
+			name:    "invalid column",
+			builder: NewDeleter[TestModel](db).Where(C("xxxx").Eq(18)),
+			wantErr: errs.NewErrUnknownField("xxxx"),
+		},
+
+		{
+			name:    "optimizer hints",
+			builder: NewDeleter[TestModel](db).Hints("MAX_EXECUTION_TIME(1000)").Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "DELETE /*+ MAX_EXECUTION_TIME(1000) */ FROM `test_model` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+
+		{
+			// 只传了实体的时候按照主键删除
+			name:    "entity",
+			builder: NewDeleter[TestModel](db).Delete(&TestModel{Id: 5, Age: 18}),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `test_model` WHERE `id` = ?;",
+				Args: []any{int64(5)},
+			},
+		},
+
+		{
+			name:    "entity composite key",
+			builder: NewDeleter[CompositeKeyModel](db).Delete(&CompositeKeyModel{UserId: 1, OrderId: 2}),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `composite_key_model` WHERE (`user_id` = ?) AND (`order_id` = ?);",
+				Args: []any{int64(1), int64(2)},
+			},
+		},
+
+		{
+			// Where 优先
+			name:    "entity with where",
+			builder: NewDeleter[TestModel](db).Delete(&TestModel{Id: 5}).Where(C("Age").Eq(18)),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `test_model` WHERE `age` = ?;",
+				Args: []any{18},
+			},
+		},
+
+		{
+			name:    "entity no primary key",
+			builder: NewDeleter[NoKeyModel](db).Delete(&NoKeyModel{}),
+			wantErr: errs.ErrNoPrimaryKey,
+		},
+
+		{
+			name:    "sqlite optimizer hints",
+			builder: NewDeleter[TestModel](sqliteDB).Hints("MAX_EXECUTION_TIME(1000)"),
+			wantErr: errs.NewErrUnsupportedHint("sqlite"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, query)
+		})
+	}
+}
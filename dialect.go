@@ -0,0 +1,145 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"strings"
+)
+
+var (
+	MySQL  Dialect = &mysqlDialect{}
+	SQLite Dialect = &sqliteDialect{}
+)
+
+// Dialect 方言, 用于屏蔽不同数据库之间的语法差异
+type Dialect interface {
+	// name 方言的名字, 主要用于错误信息
+	name() string
+	// buildOptimizerHints 构造 /*+ ... */ 形式的优化器提示
+	// 位置紧跟在 SELECT、UPDATE、DELETE 关键字之后
+	buildOptimizerHints(b *builder, hints []string) error
+	// buildIndexHints 构造 USE INDEX 之类的索引提示, 位置紧跟在表名之后
+	buildIndexHints(b *builder, hints []indexHint) error
+	// noLimit 不限制行数的 LIMIT 的值, 只有 OFFSET 没有 LIMIT 的时候使用
+	noLimit() string
+
+	// savepoint 创建保存点的语句, 用于嵌套事务
+	savepoint(name string) string
+	// releaseSavepoint 释放保存点的语句
+	releaseSavepoint(name string) string
+	// rollbackToSavepoint 回滚到保存点的语句
+	rollbackToSavepoint(name string) string
+
+	// explain 查看执行计划的关键字
+	explain() string
+	// planRow 把执行计划的原始数据转换为 PlanRow
+	planRow(raw map[string]string) PlanRow
+}
+
+// standardSQL 不支持任何提示, 有提示就直接报错, 避免用户以为提示生效了
+type standardSQL struct {
+}
+
+func (s standardSQL) name() string {
+	return "standard"
+}
+
+func (s standardSQL) buildOptimizerHints(b *builder, hints []string) error {
+	if len(hints) > 0 {
+		return errs.NewErrUnsupportedHint(b.dialect.name())
+	}
+	return nil
+}
+
+func (s standardSQL) buildIndexHints(b *builder, hints []indexHint) error {
+	if len(hints) > 0 {
+		return errs.NewErrUnsupportedHint(b.dialect.name())
+	}
+	return nil
+}
+
+func (s standardSQL) savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (s standardSQL) releaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (s standardSQL) rollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+type mysqlDialect struct {
+	standardSQL
+}
+
+func (m *mysqlDialect) name() string {
+	return "mysql"
+}
+
+// buildOptimizerHints SELECT /*+ MAX_EXECUTION_TIME(1000) BKA(t1) */ * FROM ...
+func (m *mysqlDialect) buildOptimizerHints(b *builder, hints []string) error {
+	if len(hints) == 0 {
+		return nil
+	}
+	b.sb.WriteString(" /*+ ")
+	b.sb.WriteString(strings.Join(hints, " "))
+	b.sb.WriteString(" */")
+	return nil
+}
+
+// buildIndexHints FROM `t` USE INDEX (`idx_a`,`idx_b`) FORCE INDEX (`idx_c`)
+func (m *mysqlDialect) buildIndexHints(b *builder, hints []indexHint) error {
+	for _, h := range hints {
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(h.typ)
+		b.sb.WriteString(" (")
+		for i, idx := range h.indexes {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.quote(idx)
+		}
+		b.sb.WriteByte(')')
+	}
+	return nil
+}
+
+// noLimit MySQL 不能单独使用 OFFSET, 官方文档建议用 BIGINT UNSIGNED 的最大值
+func (m *mysqlDialect) noLimit() string {
+	return "18446744073709551615"
+}
+
+type sqliteDialect struct {
+	standardSQL
+}
+
+func (s *sqliteDialect) name() string {
+	return "sqlite"
+}
+
+// noLimit SQLite 里面负数表示不限制
+func (s *sqliteDialect) noLimit() string {
+	return "-1"
+}
+
+// indexHint 索引提示
+type indexHint struct {
+	// typ USE INDEX, FORCE INDEX 或者 IGNORE INDEX
+	typ     string
+	indexes []string
+}
+
+const (
+	hintUseIndex    = "USE INDEX"
+	hintForceIndex  = "FORCE INDEX"
+	hintIgnoreIndex = "IGNORE INDEX"
+)
+
+// appendIndexHint 没有传索引的调用直接忽略, 否则会生成 FORCE INDEX () 这种非法 SQL
+func appendIndexHint(hints []indexHint, typ string, indexes []string) []indexHint {
+	if len(indexes) == 0 {
+		return hints
+	}
+	return append(hints, indexHint{typ: typ, indexes: indexes})
+}
@@ -0,0 +1,138 @@
+package Go_ORM
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// PlanRow 执行计划的一行
+type PlanRow struct {
+	Table string
+	// Type 访问类型, 只有 MySQL 有, 例如 ALL, ref, const
+	Type string
+	// Key 实际使用的索引, 只有 MySQL 有
+	Key string
+	// Rows 预估扫描的行数, 只有 MySQL 有
+	Rows int64
+	// Detail MySQL 里面是 Extra, SQLite 里面是 detail
+	Detail string
+	// FullScan 是否全表扫描
+	FullScan bool
+	// Raw 原始的列名和值, 不同数据库返回的列不一样
+	Raw map[string]string
+}
+
+// Explain 返回查询的执行计划, 不会经过 Middleware
+func (s *Selector[T]) Explain(ctx context.Context) ([]PlanRow, error) {
+	s.ctx = ctx
+	q, err := s.Build()
+	if err != nil {
+		return nil, err
+	}
+	return explain(ctx, s.sess, q)
+}
+
+func explain(ctx context.Context, sess Session, q *Query) ([]PlanRow, error) {
+	dialect := sess.getCore().dialect
+	rows, err := queryOn(ctx, sess, &Query{
+		SQL:  dialect.explain() + " " + q.SQL,
+		Args: q.Args,
+		DB:   q.DB,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cs, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var res []PlanRow
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cs))
+		ptrs := make([]any, len(cs))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		raw := make(map[string]string, len(cs))
+		for i, c := range cs {
+			raw[c] = vals[i].String
+		}
+		res = append(res, dialect.planRow(raw))
+	}
+	return res, rows.Err()
+}
+
+// DBWithFullScanWarning 每个 SELECT 执行之前先 EXPLAIN, 发现全表扫描就调用 warn
+// 这个 Middleware 会加在已经注册的 Middleware 后面, EXPLAIN 失败不影响查询本身
+// 每次查询都会多一次 EXPLAIN, 适合在测试环境使用
+// EXPLAIN 和查询本身在同一个连接上执行, 不会多占一次从库的轮询
+func DBWithFullScanWarning(warn func(ctx context.Context, qc *QueryContext, plan []PlanRow)) DBOption {
+	return DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			if qc.Type != OpSelect || qc.sess == nil {
+				return next(ctx, qc)
+			}
+			if db, ok := qc.sess.(*DB); ok && qc.Query.DB == "" {
+				ctx = db.pinReplica(ctx)
+			}
+			plan, err := explain(ctx, qc.sess, qc.Query)
+			if err == nil {
+				for _, row := range plan {
+					if row.FullScan {
+						warn(ctx, qc, plan)
+						break
+					}
+				}
+			}
+			return next(ctx, qc)
+		}
+	})
+}
+
+func (s standardSQL) explain() string {
+	return "EXPLAIN"
+}
+
+func (s standardSQL) planRow(raw map[string]string) PlanRow {
+	return PlanRow{Raw: raw}
+}
+
+// planRow MySQL 的 type 是 ALL 就是全表扫描
+func (m *mysqlDialect) planRow(raw map[string]string) PlanRow {
+	rows, _ := strconv.ParseInt(raw["rows"], 10, 64)
+	return PlanRow{
+		Table:    raw["table"],
+		Type:     raw["type"],
+		Key:      raw["key"],
+		Rows:     rows,
+		Detail:   raw["Extra"],
+		FullScan: raw["type"] == "ALL",
+		Raw:      raw,
+	}
+}
+
+func (s *sqliteDialect) explain() string {
+	return "EXPLAIN QUERY PLAN"
+}
+
+// planRow SQLite 的 detail 是 "SCAN test_model" 或者 "SEARCH test_model USING INDEX idx_age (age=?)"
+// SCAN 并且没有使用索引的就是全表扫描
+func (s *sqliteDialect) planRow(raw map[string]string) PlanRow {
+	detail := raw["detail"]
+	segs := strings.Fields(detail)
+	res := PlanRow{
+		Detail: detail,
+		Raw:    raw,
+	}
+	if len(segs) >= 2 {
+		res.Table = segs[1]
+	}
+	res.FullScan = len(segs) > 0 && segs[0] == "SCAN" && !strings.Contains(detail, " USING ")
+	return res
+}
@@ -0,0 +1,100 @@
+package Go_ORM
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSelector_Explain(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	_, err := db.db.ExecContext(ctx, "CREATE INDEX idx_age ON test_model(age)")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		selector *Selector[TestModel]
+
+		wantFullScan bool
+		wantDetail   string
+	}{
+		{
+			name:         "full scan",
+			selector:     NewSelector[TestModel](db).Where(C("FirstName").Eq("Tom")),
+			wantFullScan: true,
+			wantDetail:   "SCAN test_model",
+		},
+
+		{
+			name:       "primary key",
+			selector:   NewSelector[TestModel](db).Where(C("Id").Eq(1)),
+			wantDetail: "SEARCH test_model USING INTEGER PRIMARY KEY (rowid=?)",
+		},
+
+		{
+			name:       "index",
+			selector:   NewSelector[TestModel](db).Where(C("Age").Eq(18)),
+			wantDetail: "SEARCH test_model USING INDEX idx_age (age=?)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := tc.selector.Explain(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(plan))
+			assert.Equal(t, "test_model", plan[0].Table)
+			assert.Equal(t, tc.wantDetail, plan[0].Detail)
+			assert.Equal(t, tc.wantFullScan, plan[0].FullScan)
+		})
+	}
+}
+
+func TestMySQLPlanRow(t *testing.T) {
+	row := MySQL.planRow(map[string]string{
+		"id":    "1",
+		"table": "test_model",
+		"type":  "ALL",
+		"key":   "",
+		"rows":  "1000",
+		"Extra": "Using where",
+	})
+	assert.Equal(t, "test_model", row.Table)
+	assert.Equal(t, int64(1000), row.Rows)
+	assert.Equal(t, "Using where", row.Detail)
+	assert.True(t, row.FullScan)
+
+	row = MySQL.planRow(map[string]string{"table": "test_model", "type": "const", "key": "PRIMARY", "rows": "1"})
+	assert.Equal(t, "PRIMARY", row.Key)
+	assert.False(t, row.FullScan)
+}
+
+func TestDBWithFullScanWarning(t *testing.T) {
+	var warnings []string
+	db := newSQLiteDB(t, DBWithFullScanWarning(func(ctx context.Context, qc *QueryContext, plan []PlanRow) {
+		warnings = append(warnings, qc.Query.SQL)
+	}))
+	ctx := context.Background()
+	_, err := NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = NewSelector[TestModel](db).Where(C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	res, err := NewSelector[TestModel](db).Where(C("FirstName").Eq("Tom")).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, []string{"SELECT * FROM `test_model` WHERE `first_name` = ?;"}, warnings)
+}
+
+func TestDBWithFullScanWarning_Replicas(t *testing.T) {
+	warnings := 0
+	db := newSQLiteDB(t, DBWithReplicas(newSQLiteReplica(t, 2), newSQLiteReplica(t, 3)),
+		DBWithFullScanWarning(func(ctx context.Context, qc *QueryContext, plan []PlanRow) {
+			warnings++
+		}))
+	// EXPLAIN 和查询在同一个从库上, 轮询不受影响
+	assert.Equal(t, []int64{2, 3, 2, 3}, readIds(t, context.Background(), db, 4))
+	assert.Equal(t, 4, warnings)
+}
@@ -0,0 +1,53 @@
+package Go_ORM
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeInserter Inserter 在构造 SQL 之前调用, 可以修改实体, 返回 error 会中止插入
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context, sess Session) error
+}
+
+// AfterFinder Selector 把一行数据映射成实体之后调用, 返回 error 会中止查询
+type AfterFinder interface {
+	AfterFind(ctx context.Context, sess Session) error
+}
+
+// BeforeUpdater Updater 在构造 SQL 之前调用, 接收者是 Update 传入的实体
+// 没有调用 Update 的时候不会触发
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context, sess Session) error
+}
+
+// AfterDeleter Deleter 执行成功之后调用, 接收者是 Delete 传入的实体
+// 没有调用 Delete 的时候不会触发, DeleteByID 会传入只有主键的实体
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context, sess Session) error
+}
+
+var (
+	beforeInserterType = reflect.TypeOf((*BeforeInserter)(nil)).Elem()
+	afterFinderType    = reflect.TypeOf((*AfterFinder)(nil)).Elem()
+	beforeUpdaterType  = reflect.TypeOf((*BeforeUpdater)(nil)).Elem()
+	afterDeleterType   = reflect.TypeOf((*AfterDeleter)(nil)).Elem()
+)
+
+// hooks 模型实现了哪些钩子, 在注册的时候确定
+type hooks struct {
+	beforeInsert bool
+	afterFind    bool
+	beforeUpdate bool
+	afterDelete  bool
+}
+
+// newHooks typ 是指向结构体的指针
+func newHooks(typ reflect.Type) hooks {
+	return hooks{
+		beforeInsert: typ.Implements(beforeInserterType),
+		afterFind:    typ.Implements(afterFinderType),
+		beforeUpdate: typ.Implements(beforeUpdaterType),
+		afterDelete:  typ.Implements(afterDeleterType),
+	}
+}
@@ -0,0 +1,116 @@
+package Go_ORM
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+type hookLogKey struct{}
+
+// HookModel 用的是 test_model 表
+type HookModel struct {
+	Id        int64
+	Age       int8
+	FirstName string
+	LastName  *sql.NullString
+	// FullName 由 AfterFind 设置
+	FullName string `orm:"-"`
+}
+
+func (h *HookModel) TableName() string {
+	return "test_model"
+}
+
+func (h *HookModel) BeforeInsert(ctx context.Context, sess Session) error {
+	h.log(ctx, sess, "BeforeInsert")
+	h.FirstName = strings.TrimSpace(h.FirstName)
+	return nil
+}
+
+func (h *HookModel) AfterFind(ctx context.Context, sess Session) error {
+	h.log(ctx, sess, "AfterFind")
+	h.FullName = "Mr. " + h.FirstName
+	return nil
+}
+
+func (h *HookModel) BeforeUpdate(ctx context.Context, sess Session) error {
+	h.log(ctx, sess, "BeforeUpdate")
+	if h.Age < 0 {
+		return errors.New("age must not be negative")
+	}
+	return nil
+}
+
+func (h *HookModel) AfterDelete(ctx context.Context, sess Session) error {
+	h.log(ctx, sess, fmt.Sprintf("AfterDelete %d", h.Id))
+	return nil
+}
+
+func (h *HookModel) log(ctx context.Context, sess Session, name string) {
+	logs := ctx.Value(hookLogKey{}).(*[]string)
+	if _, ok := sess.(*Tx); ok {
+		name += " in tx"
+	}
+	*logs = append(*logs, name)
+}
+
+func TestHooks(t *testing.T) {
+	db := newSQLiteDB(t)
+	m, err := db.Register(&HookModel{})
+	require.NoError(t, err)
+	assert.Equal(t, hooks{beforeInsert: true, afterFind: true, beforeUpdate: true, afterDelete: true}, m.hooks)
+
+	var logs []string
+	ctx := context.WithValue(context.Background(), hookLogKey{}, &logs)
+
+	_, err = NewInserter[HookModel](db).Values(
+		&HookModel{Id: 1, FirstName: " Tom "},
+		&HookModel{Id: 2, FirstName: "Jerry"},
+	).Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BeforeInsert", "BeforeInsert"}, logs)
+
+	logs = nil
+	hm, err := NewSelector[HookModel](db).Where(C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &HookModel{Id: 1, FirstName: "Tom", FullName: "Mr. Tom"}, hm)
+	hms, err := NewSelector[HookModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Len(t, hms, 2)
+	assert.Equal(t, []string{"AfterFind", "AfterFind", "AfterFind"}, logs)
+
+	// 返回 error 会中止更新
+	logs = nil
+	hm.Age = -1
+	_, err = NewUpdater[HookModel](db).Update(hm).Set(C("Age")).Where(C("Id").Eq(1)).Exec(ctx)
+	assert.Equal(t, errors.New("age must not be negative"), err)
+	// 没有实体不会触发
+	_, err = NewUpdater[HookModel](db).Set(Assign("Age", 18)).Where(C("Id").Eq(1)).Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BeforeUpdate"}, logs)
+
+	logs = nil
+	deleted := &HookModel{Id: 1, FirstName: "Tom"}
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		_, err := NewDeleter[HookModel](tx).Delete(deleted).Where(C("Id").Eq(1)).Exec(ctx)
+		return err
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AfterDelete 1 in tx"}, logs)
+	_, err = NewSelector[HookModel](db).Where(C("Id").Eq(1)).Get(ctx)
+	assert.Equal(t, ErrNoRows, err)
+
+	// 没有实体不会触发, DeleteByID 会传入只有主键的实体
+	logs = nil
+	_, err = NewDeleter[HookModel](db).Where(C("Id").Eq(3)).Exec(ctx)
+	require.NoError(t, err)
+	_, err = DeleteByID[HookModel](ctx, db, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AfterDelete 2"}, logs)
+}
@@ -0,0 +1,201 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+type Inserter[T any] struct {
+	builder
+	table   string
+	values  []*T
+	columns []string
+
+	sess Session
+}
+
+func NewInserter[T any](sess Session) *Inserter[T] {
+	return &Inserter[T]{
+		builder: builder{
+			core: sess.getCore(),
+		},
+		sess: sess,
+	}
+}
+
+// Values 要插入的数据, 一次可以插入多行
+func (i *Inserter[T]) Values(vals ...*T) *Inserter[T] {
+	i.values = vals
+	return i
+}
+
+// Columns 指定插入的列, 传入的是字段名
+// 不调用的话就插入全部列
+func (i *Inserter[T]) Columns(cols ...string) *Inserter[T] {
+	i.columns = cols
+	return i
+}
+
+func (i *Inserter[T]) From(table string) *Inserter[T] {
+	i.table = table
+	return i
+}
+
+func (i *Inserter[T]) Build() (*Query, error) {
+	if len(i.values) == 0 {
+		return nil, errs.ErrInsertZeroRow
+	}
+	i.reset()
+	var err error
+	i.model, err = i.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	i.fillAutoTime()
+	table, db, err := i.shardingWriteTarget(i.table, func(c *shardingConfig) ([]Dst, error) {
+		vals := make([]reflect.Value, 0, len(i.values))
+		for _, v := range i.values {
+			vals = append(vals, reflect.ValueOf(v).Elem())
+		}
+		return c.routeValues(i.model, vals)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sb := i.sb
+	sb.WriteString("INSERT INTO ")
+	if err = i.checkTables(table); err != nil {
+		return nil, err
+	}
+	if err = i.buildTable(table, i.values[0]); err != nil {
+		return nil, err
+	}
+
+	var fields []*Field
+	if len(i.columns) == 0 {
+		// 按照结构体里面字段定义的顺序插入
+		fields = make([]*Field, 0, len(i.model.fields))
+		for _, fd := range i.model.fields {
+			if fd.autoIncrement && i.allZero(fd) {
+				continue
+			}
+			fields = append(fields, fd)
+		}
+	} else {
+		fields = make([]*Field, 0, len(i.columns))
+		for _, c := range i.columns {
+			fd, ok := i.model.fileMap[c]
+			if !ok {
+				return nil, errs.NewErrUnknownField(c)
+			}
+			fields = append(fields, fd)
+		}
+	}
+
+	sb.WriteByte('(')
+	for j, fd := range fields {
+		if j > 0 {
+			sb.WriteByte(',')
+		}
+		i.quote(fd.colName)
+	}
+	sb.WriteString(") VALUES ")
+
+	for j, v := range i.values {
+		if j > 0 {
+			sb.WriteByte(',')
+		}
+		val := reflect.ValueOf(v).Elem()
+		sb.WriteByte('(')
+		for k, fd := range fields {
+			if k > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('?')
+			i.addArg(val.FieldByIndex(fd.index).Interface())
+		}
+		sb.WriteByte(')')
+	}
+
+	sb.WriteByte(';')
+	return &Query{
+		SQL:  sb.String(),
+		Args: i.args,
+		DB:   db,
+	}, nil
+}
+
+// checkTables 每一行都要经过 TableNameResolver, 落到不同的表上就返回错误
+func (i *Inserter[T]) checkTables(table string) error {
+	if table != "" || i.model.tableResolver == nil || len(i.values) == 1 {
+		return nil
+	}
+	ctx := i.context()
+	first, err := i.model.resolveTableName(ctx, i.values[0])
+	if err != nil {
+		return err
+	}
+	for _, v := range i.values[1:] {
+		name, err := i.model.resolveTableName(ctx, v)
+		if err != nil {
+			return err
+		}
+		if name != first {
+			return errs.ErrInsertMultipleTables
+		}
+	}
+	return nil
+}
+
+// fillAutoTime 没有设置值的自动时间设置成当前时间, 会修改传入的实体
+func (i *Inserter[T]) fillAutoTime() {
+	now := i.now()
+	for _, v := range i.values {
+		val := reflect.ValueOf(v).Elem()
+		for _, fd := range i.model.fields {
+			if !fd.autoCreateTime && !fd.autoUpdateTime {
+				continue
+			}
+			if fv := val.FieldByIndex(fd.index); fv.IsZero() {
+				fv.Set(fd.autoTimeValue(now))
+			}
+		}
+	}
+}
+
+// allZero 自增列在所有行里面都没有设置值, 就交给数据库生成
+func (i *Inserter[T]) allZero(fd *Field) bool {
+	for _, v := range i.values {
+		if !reflect.ValueOf(v).Elem().FieldByIndex(fd.index).IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *Inserter[T]) Exec(ctx context.Context) (sql.Result, error) {
+	i.ctx = ctx
+	m, err := i.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	if m.hooks.beforeInsert {
+		for _, v := range i.values {
+			if err = any(v).(BeforeInserter).BeforeInsert(ctx, i.sess); err != nil {
+				return nil, err
+			}
+		}
+	}
+	q, err := i.Build()
+	if err != nil {
+		return nil, err
+	}
+	return exec(ctx, i.sess, &QueryContext{
+		Type:    OpInsert,
+		Builder: i,
+		Query:   q,
+		Model:   i.model,
+	})
+}
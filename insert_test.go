@@ -0,0 +1,103 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInserter_Build(t *testing.T) {
+	db, err := NewDB()
+	require.NoError(t, err)
+	testCases := []struct {
+		name    string
+		builder QueryBuilder
+
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "no values",
+			builder: NewInserter[TestModel](db),
+			wantErr: errs.ErrInsertZeroRow,
+		},
+
+		{
+			name: "single row",
+			builder: NewInserter[TestModel](db).Values(&TestModel{
+				Id:        1,
+				Age:       18,
+				FirstName: "Tom",
+				LastName:  &sql.NullString{String: "Jerry", Valid: true},
+			}),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `test_model`(`id`,`age`,`first_name`,`last_name`) VALUES (?,?,?,?);",
+				Args: []any{int64(1), int8(18), "Tom",
+					&sql.NullString{String: "Jerry", Valid: true}},
+			},
+		},
+
+		{
+			name: "multiple rows",
+			builder: NewInserter[TestModel](db).Values(
+				&TestModel{Id: 1, Age: 18, FirstName: "Tom"},
+				&TestModel{Id: 2, Age: 19, FirstName: "Jerry"},
+			).Columns("Id", "FirstName"),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `test_model`(`id`,`first_name`) VALUES (?,?),(?,?);",
+				Args: []any{int64(1), "Tom", int64(2), "Jerry"},
+			},
+		},
+
+		{
+			// 自增主键没有设置值, 交给数据库生成
+			name: "autoincrement",
+			builder: NewInserter[AutoIncrModel](db).Values(
+				&AutoIncrModel{Name: "Tom"},
+				&AutoIncrModel{Name: "Jerry"},
+			),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `auto_incr_model`(`name`) VALUES (?),(?);",
+				Args: []any{"Tom", "Jerry"},
+			},
+		},
+
+		{
+			// 有一行设置了值, 就要插入这一列
+			name: "autoincrement with value",
+			builder: NewInserter[AutoIncrModel](db).Values(
+				&AutoIncrModel{Name: "Tom"},
+				&AutoIncrModel{Id: 12, Name: "Jerry"},
+			),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `auto_incr_model`(`id`,`name`) VALUES (?,?),(?,?);",
+				Args: []any{int64(0), "Tom", int64(12), "Jerry"},
+			},
+		},
+
+		{
+			name:    "invalid column",
+			builder: NewInserter[TestModel](db).Values(&TestModel{}).Columns("xxxx"),
+			wantErr: errs.NewErrUnknownField("xxxx"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, query)
+		})
+	}
+}
+
+type AutoIncrModel struct {
+	Id      int64 `orm:"pk,autoincr"`
+	Name    string
+	Ignored string `orm:"-"`
+}
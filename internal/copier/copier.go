@@ -0,0 +1,50 @@
+package copier
+
+import "reflect"
+
+// DeepCopy 深拷贝 *T 和 []*T, 指针、切片和结构体的公开字段都会复制, 其它类型原样返回
+// Middleware 把同一个查询结果交给多个调用者的时候, 用它避免调用者之间互相影响
+// 注意: 非公开字段和 map 仍然是共享的, 例如 time.Time 里面的 *Location
+func DeepCopy(val any) any {
+	if val == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(val)).Interface()
+}
+
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(deepCopy(v.Elem()))
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		switch v.Type().Elem().Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Struct:
+			for i := 0; i < v.Len(); i++ {
+				cp.Index(i).Set(deepCopy(v.Index(i)))
+			}
+		default:
+			reflect.Copy(cp, v)
+		}
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if fd := cp.Field(i); fd.CanSet() {
+				fd.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return cp
+	default:
+		return v
+	}
+}
@@ -0,0 +1,53 @@
+package copier
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type user struct {
+	Name     string
+	LastName *sql.NullString
+	Avatar   []byte
+	Birthday time.Time
+	Addr     address
+}
+
+type address struct {
+	City *string
+}
+
+func TestDeepCopy(t *testing.T) {
+	city := "Shanghai"
+	u := &user{
+		Name:     "Tom",
+		LastName: &sql.NullString{String: "Cat", Valid: true},
+		Avatar:   []byte("abc"),
+		Birthday: time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local),
+		Addr:     address{City: &city},
+	}
+	cp := DeepCopy(u).(*user)
+	assert.Equal(t, u, cp)
+	assert.NotSame(t, u, cp)
+	// 修改指针字段和切片不会影响原来的值
+	cp.LastName.String = "Dog"
+	cp.Avatar[0] = 'x'
+	*cp.Addr.City = "Beijing"
+	assert.Equal(t, "Cat", u.LastName.String)
+	assert.Equal(t, []byte("abc"), u.Avatar)
+	assert.Equal(t, "Shanghai", *u.Addr.City)
+
+	us := []*user{{Name: "Tom", LastName: &sql.NullString{String: "Cat"}}, nil}
+	cps := DeepCopy(us).([]*user)
+	assert.Equal(t, us, cps)
+	assert.NotSame(t, us[0], cps[0])
+	cps[0].LastName.String = "Dog"
+	assert.Equal(t, "Cat", us[0].LastName.String)
+
+	var nilUser *user
+	assert.Equal(t, nilUser, DeepCopy(nilUser))
+	assert.Equal(t, 1, DeepCopy(1))
+	assert.Nil(t, DeepCopy(nil))
+}
@@ -3,9 +3,28 @@ package errs
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
-var ErrPointerOnly = errors.New("orm:只支持指向结构体的一级指针")
+var (
+	ErrPointerOnly      = errors.New("orm:只支持指向结构体的一级指针")
+	ErrNoUpdatedColumns = errors.New("orm: 没有指定更新的列")
+	ErrNoRows           = errors.New("orm: 没有数据")
+	ErrInsertZeroRow    = errors.New("orm: 插入0行")
+	ErrUnsafeDML        = errors.New("orm: 不安全的查询")
+	ErrNoPrimaryKey     = errors.New("orm: 模型没有主键")
+	ErrNoConnection     = errors.New("orm: DB 没有连接, 只能用来构造 SQL")
+	// ErrInsertMultipleTables 一次插入的多行数据只能落到同一张表上
+	ErrInsertMultipleTables = errors.New("orm: 插入的数据落到了不同的表上")
+
+	ErrShardingMultipleTargets = errors.New("orm: 查询会落到多个分片上, 只能使用 GetMulti 或者 Get")
+	ErrShardingNoTarget        = errors.New("orm: 查询条件没有命中任何分片")
+	ErrShardingOffset          = errors.New("orm: 跨分片查询不支持 OFFSET")
+	ErrShardingInTx            = errors.New("orm: 事务里面不能查询其它分库")
+	// ErrShardingMultipleWriteTargets 写操作不支持跨分片
+	ErrShardingMultipleWriteTargets = errors.New("orm: 写操作会落到多个分片上")
+	ErrShardingKeyUpdate            = errors.New("orm: 不能修改分片键")
+)
 
 func NewErrUnsupportedExpression(expr any) error {
 	return fmt.Errorf("orm: 不支持的表达式 %v", expr)
@@ -15,6 +34,74 @@ func NewErrUnknownField(name string) error {
 	return fmt.Errorf("orm: 未知字段 %s", name)
 }
 
+func NewErrUnknownColumn(col string) error {
+	return fmt.Errorf("orm: 未知列 %s", col)
+}
+
 func NewErrInvalidTagContent(pair string) error {
 	return fmt.Errorf("orm: 非法标签值 %s", pair)
 }
+
+func NewErrUnsupportedHint(dialect string) error {
+	return fmt.Errorf("orm: 方言 %s 不支持索引提示或优化器提示", dialect)
+}
+
+func NewErrUnsupportedAssignable(expr any) error {
+	return fmt.Errorf("orm: 不支持的赋值表达式 %v", expr)
+}
+
+// NewErrFailedToRollbackTx 保留业务错误, 方便用户用 errors.Is 判断
+func NewErrFailedToRollbackTx(bizErr error, rbErr error) error {
+	return fmt.Errorf("orm: 事务回滚失败, 业务错误: %w, 回滚错误: %s", bizErr, rbErr.Error())
+}
+
+// NewErrUnsafeDML 可以用 errors.Is(err, ErrUnsafeDML) 判断
+func NewErrUnsafeDML(typ string, table string, reason string) error {
+	return fmt.Errorf("%w: %s %s %s", ErrUnsafeDML, typ, table, reason)
+}
+
+func NewErrUnsupportedShardingValue(val any) error {
+	return fmt.Errorf("orm: 不支持的分片键的值 %v", val)
+}
+
+func NewErrPrimaryKeyCount(want int, got int) error {
+	return fmt.Errorf("orm: 主键有 %d 列, 但是传入了 %d 个值", want, got)
+}
+
+// NewErrFieldConflict 展开组合的结构体之后, 字段名或者列名重复了
+func NewErrFieldConflict(typ string, name string) error {
+	return fmt.Errorf("orm: %s 里面的字段或者列 %s 重复了", typ, name)
+}
+
+func NewErrUnsupportedFieldType(typ string, field string, fieldType string) error {
+	return fmt.Errorf("orm: %s.%s 的类型 %s 不能映射到列", typ, field, fieldType)
+}
+
+func NewErrModelNotRegistered(typ string) error {
+	return fmt.Errorf("orm: 模型 %s 没有注册", typ)
+}
+
+// NewErrRegisterModel 在错误信息里面加上模型的类型
+func NewErrRegisterModel(typ any, err error) error {
+	return fmt.Errorf("%v: %w", typ, err)
+}
+
+// NewErrRegisterModels 把多个模型的注册错误合并成一个
+func NewErrRegisterModels(errList []error) error {
+	return registerErrors(errList)
+}
+
+type registerErrors []error
+
+func (e registerErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "orm: 注册模型失败\n" + strings.Join(msgs, "\n")
+}
+
+// Unwrap Go 1.20 之后 errors.Is 和 errors.As 可以识别它
+func (e registerErrors) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,118 @@
+package Go_ORM
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	OpSelect = "SELECT"
+	OpInsert = "INSERT"
+	OpUpdate = "UPDATE"
+	OpDelete = "DELETE"
+)
+
+// QueryContext 一次查询的上下文
+type QueryContext struct {
+	// Type 查询类型, 即 SELECT, INSERT, UPDATE 和 DELETE
+	Type string
+	// Builder 大多数情况下需要转换到具体的类型才能使用
+	Builder QueryBuilder
+	// Query 已经构造好的查询, Middleware 可以替换它, 最终执行的是替换后的查询
+	Query *Query
+	Model *Model
+
+	// sess 执行查询的会话, 给内置的 Middleware 使用
+	sess Session
+	// multi GetMulti 为 true
+	multi bool
+}
+
+// Multi Get 和 GetMulti 的结果类型不同, 同样的 SQL 也要区分开
+func (qc *QueryContext) Multi() bool {
+	return qc.multi
+}
+
+// Key 同样的分库、SQL 和参数就是同一个查询, 给缓存和 singleflight 之类的 Middleware 使用
+// Get 和 GetMulti 的结果类型不同, 所以要区分开; 指针参数按照它指向的值计算
+func (qc *QueryContext) Key() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%t %s %s", qc.multi, qc.Query.DB, qc.Query.SQL)
+	for _, arg := range qc.Query.Args {
+		sb.WriteByte(' ')
+		fmt.Fprintf(&sb, "%#v", derefArg(arg))
+	}
+	return sb.String()
+}
+
+// derefArg 直接打印指针得到的是地址, 每次查询都不一样
+func derefArg(arg any) any {
+	val := reflect.ValueOf(arg)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return nil
+	}
+	return val.Interface()
+}
+
+// Tx 查询所在的事务, 用 *Tx 执行或者 ctx 里面带了事务都算
+// 事务里面的查询能看到未提交的数据, 缓存之类的 Middleware 要跳过它们
+func (qc *QueryContext) Tx(ctx context.Context) (*Tx, bool) {
+	switch sess := qc.sess.(type) {
+	case *Tx:
+		return sess, true
+	case *DB:
+		return sess.txFromContext(ctx)
+	default:
+		return nil, false
+	}
+}
+
+// QueryResult 一次查询的结果
+type QueryResult struct {
+	// Result 在不同的查询里面, 类型是不同的
+	// Selector.Get 里面, 这会是单个结果 *T
+	// Selector.GetMulti 里面, 这会是 []*T
+	// 其它情况下, 它会是 sql.Result
+	Result any
+	Err    error
+}
+
+type Handler func(ctx context.Context, qc *QueryContext) *QueryResult
+
+type Middleware func(next Handler) Handler
+
+// DBWithMiddlewares 所有的 Get、GetMulti 和 Exec 都会经过这些 Middleware
+// 先注册的在外层
+func DBWithMiddlewares(mdls ...Middleware) DBOption {
+	return func(db *DB) {
+		db.mdls = append(db.mdls, mdls...)
+	}
+}
+
+// handle 把 root 用 Middleware 包起来再执行
+func (c core) handle(ctx context.Context, qc *QueryContext, root Handler) *QueryResult {
+	for i := len(c.mdls) - 1; i >= 0; i-- {
+		root = c.mdls[i](root)
+	}
+	return root(ctx, qc)
+}
+
+// exec Inserter、Updater 和 Deleter 的公共部分
+func exec(ctx context.Context, sess Session, qc *QueryContext) (sql.Result, error) {
+	qc.sess = sess
+	res := sess.getCore().handle(ctx, qc, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		r, err := execOn(ctx, sess, qc.Query)
+		return &QueryResult{Result: r, Err: err}
+	})
+	r, _ := res.Result.(sql.Result)
+	return r, res.Err
+}
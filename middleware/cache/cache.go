@@ -0,0 +1,14 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache 缓存的抽象, 按照表名分组, 这样写操作的时候可以把整张表的缓存删掉
+type Cache interface {
+	Get(ctx context.Context, table string, key string) (any, bool)
+	Set(ctx context.Context, table string, key string, val any, ttl time.Duration)
+	// Invalidate 删除 table 的所有缓存
+	Invalidate(ctx context.Context, table string)
+}
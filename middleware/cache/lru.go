@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	table    string
+	key      string
+	val      any
+	deadline time.Time
+}
+
+// LRU 进程内的缓存, 超过容量之后淘汰最久没有使用的
+type LRU struct {
+	capacity int
+
+	mutex sync.Mutex
+	// list 头部是最近使用的
+	list   *list.List
+	tables map[string]map[string]*list.Element
+
+	now func() time.Time
+}
+
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		list:     list.New(),
+		tables:   make(map[string]map[string]*list.Element, 8),
+		now:      time.Now,
+	}
+}
+
+func (l *LRU) Get(ctx context.Context, table string, key string) (any, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	elem, ok := l.tables[table][key]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if !l.now().Before(e.deadline) {
+		l.remove(elem)
+		return nil, false
+	}
+	l.list.MoveToFront(elem)
+	return e.val, true
+}
+
+func (l *LRU) Set(ctx context.Context, table string, key string, val any, ttl time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	deadline := l.now().Add(ttl)
+	if elem, ok := l.tables[table][key]; ok {
+		e := elem.Value.(*entry)
+		e.val = val
+		e.deadline = deadline
+		l.list.MoveToFront(elem)
+		return
+	}
+	keys, ok := l.tables[table]
+	if !ok {
+		keys = make(map[string]*list.Element, 8)
+		l.tables[table] = keys
+	}
+	keys[key] = l.list.PushFront(&entry{
+		table:    table,
+		key:      key,
+		val:      val,
+		deadline: deadline,
+	})
+	for l.list.Len() > l.capacity {
+		l.remove(l.list.Back())
+	}
+}
+
+func (l *LRU) Invalidate(ctx context.Context, table string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, elem := range l.tables[table] {
+		l.list.Remove(elem)
+	}
+	delete(l.tables, table)
+}
+
+// Len 缓存的数量, 包括已经过期但还没有被删除的
+func (l *LRU) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.list.Len()
+}
+
+func (l *LRU) remove(elem *list.Element) {
+	e := l.list.Remove(elem).(*entry)
+	keys := l.tables[e.table]
+	delete(keys, e.key)
+	if len(keys) == 0 {
+		delete(l.tables, e.table)
+	}
+}
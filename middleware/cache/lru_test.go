@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	l := NewLRU(2)
+	l.now = func() time.Time {
+		return now
+	}
+
+	l.Set(ctx, "user", "k1", 1, time.Minute)
+	l.Set(ctx, "user", "k2", 2, time.Minute)
+	// 访问 k1, 淘汰的就是 k2
+	val, ok := l.Get(ctx, "user", "k1")
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+	l.Set(ctx, "order", "k3", 3, time.Second)
+	_, ok = l.Get(ctx, "user", "k2")
+	assert.False(t, ok)
+	assert.Equal(t, 2, l.Len())
+
+	// 过期
+	now = now.Add(time.Second)
+	_, ok = l.Get(ctx, "order", "k3")
+	assert.False(t, ok)
+	assert.Equal(t, 1, l.Len())
+
+	// 覆盖
+	l.Set(ctx, "user", "k1", 11, time.Minute)
+	val, ok = l.Get(ctx, "user", "k1")
+	assert.True(t, ok)
+	assert.Equal(t, 11, val)
+
+	// 按表失效
+	l.Set(ctx, "order", "k3", 3, time.Minute)
+	l.Invalidate(ctx, "user")
+	_, ok = l.Get(ctx, "user", "k1")
+	assert.False(t, ok)
+	val, ok = l.Get(ctx, "order", "k3")
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+	assert.Equal(t, 1, l.Len())
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"Go_ORM"
+	"Go_ORM/internal/copier"
+	"context"
+	"time"
+)
+
+type ttlKey struct{}
+
+// WithTTL 缓存是按查询开启的, 只有用这个 ctx 执行的 Get 和 GetMulti 才会走缓存
+func WithTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, ttlKey{}, ttl)
+}
+
+type MiddlewareBuilder struct {
+	cache Cache
+}
+
+func NewMiddlewareBuilder(cache Cache) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		cache: cache,
+	}
+}
+
+func (b *MiddlewareBuilder) Build() Go_ORM.Middleware {
+	return func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			if qc.Model == nil {
+				return next(ctx, qc)
+			}
+			table := qc.Model.TableName()
+			tx, inTx := qc.Tx(ctx)
+			if qc.Type != Go_ORM.OpSelect {
+				res := next(ctx, qc)
+				if res.Err != nil {
+					return res
+				}
+				// 写操作成功了, 这张表的缓存全部失效
+				// 事务里面的写操作要等到提交之后, 不然其它查询可能会把提交之前的数据又缓存起来
+				if inTx {
+					tx.OnCommit(func() {
+						b.cache.Invalidate(context.Background(), table)
+					})
+				} else {
+					b.cache.Invalidate(ctx, table)
+				}
+				return res
+			}
+
+			// 事务里面能看到未提交的数据, 不能缓存
+			// 要求读主库的查询要读到最新的数据, 不能用缓存
+			ttl, ok := ctx.Value(ttlKey{}).(time.Duration)
+			if !ok || ttl <= 0 || inTx || Go_ORM.IsUseMaster(ctx) {
+				return next(ctx, qc)
+			}
+			key := qc.Key()
+			if val, ok := b.cache.Get(ctx, table, key); ok {
+				return &Go_ORM.QueryResult{Result: copier.DeepCopy(val)}
+			}
+			res := next(ctx, qc)
+			// 错误不缓存, 包括查询不到数据
+			if res.Err == nil {
+				b.cache.Set(ctx, table, key, copier.DeepCopy(res.Result), ttl)
+			}
+			return res
+		}
+	}
+}
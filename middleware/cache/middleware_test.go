@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"Go_ORM"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type TestModel struct {
+	Id        int64
+	FirstName string
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	_, err = sqlDB.Exec("CREATE TABLE test_model(id INTEGER PRIMARY KEY, first_name TEXT)")
+	require.NoError(t, err)
+
+	queries := 0
+	counter := func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			if qc.Type == Go_ORM.OpSelect {
+				queries++
+			}
+			return next(ctx, qc)
+		}
+	}
+	c := NewLRU(16)
+	db, err := Go_ORM.OpenDB(sqlDB, Go_ORM.DBWithDialect(Go_ORM.SQLite),
+		Go_ORM.DBWithMiddlewares(NewMiddlewareBuilder(c).Build(), counter))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	cacheCtx := WithTTL(ctx, time.Minute)
+	_, err = Go_ORM.NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+
+	// 第一次查数据库, 第二次走缓存
+	tm, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(cacheCtx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, tm)
+	// 修改结果不会污染缓存
+	tm.FirstName = "changed"
+	tm, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(cacheCtx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, tm)
+	assert.Equal(t, 1, queries)
+
+	// 参数不同, 是不同的查询
+	_, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(2)).Get(cacheCtx)
+	assert.Equal(t, Go_ORM.ErrNoRows, err)
+	assert.Equal(t, 2, queries)
+	// 错误不缓存
+	_, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(2)).Get(cacheCtx)
+	assert.Equal(t, Go_ORM.ErrNoRows, err)
+	assert.Equal(t, 3, queries)
+
+	tms, err := Go_ORM.NewSelector[TestModel](db).GetMulti(cacheCtx)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{{Id: 1, FirstName: "Tom"}}, tms)
+	_, err = Go_ORM.NewSelector[TestModel](db).GetMulti(cacheCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, queries)
+
+	// 没有开启缓存的查询
+	_, err = Go_ORM.NewSelector[TestModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, queries)
+
+	// 更新之后缓存失效
+	_, err = Go_ORM.NewUpdater[TestModel](db).Set(Go_ORM.Assign("FirstName", "Jerry")).
+		Where(Go_ORM.C("Id").Eq(1)).Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.Len())
+	tm, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(cacheCtx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Jerry"}, tm)
+	assert.Equal(t, 6, queries)
+}
+
+func newCacheDB(t *testing.T, c Cache, mdls ...Go_ORM.Middleware) *Go_ORM.DB {
+	sqlDB, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	_, err = sqlDB.Exec("CREATE TABLE test_model(id INTEGER PRIMARY KEY, first_name TEXT)")
+	require.NoError(t, err)
+	mdls = append([]Go_ORM.Middleware{NewMiddlewareBuilder(c).Build()}, mdls...)
+	db, err := Go_ORM.OpenDB(sqlDB, Go_ORM.DBWithDialect(Go_ORM.SQLite),
+		Go_ORM.DBWithMiddlewares(mdls...))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestMiddlewareBuilder_GetAndGetMulti(t *testing.T) {
+	db := newCacheDB(t, NewLRU(16))
+	ctx := WithTTL(context.Background(), time.Minute)
+	_, err := Go_ORM.NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+
+	// Limit(1) 的 GetMulti 和 Get 的 SQL 是一样的
+	tm, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, tm)
+	tms, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Limit(1).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{{Id: 1, FirstName: "Tom"}}, tms)
+	tm, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, tm)
+}
+
+func TestMiddlewareBuilder_Tx(t *testing.T) {
+	c := NewLRU(16)
+	db := newCacheDB(t, c)
+	ctx := WithTTL(context.Background(), time.Minute)
+
+	// 事务里面的查询不缓存, 回滚之后不会查到幽灵数据
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = Go_ORM.NewInserter[TestModel](tx).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+	_, err = Go_ORM.NewSelector[TestModel](tx).Where(Go_ORM.C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	assert.Equal(t, 0, c.Len())
+	_, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(ctx)
+	assert.Equal(t, Go_ORM.ErrNoRows, err)
+
+	_, err = Go_ORM.NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+	_, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Len())
+
+	// 事务里面的写操作, 提交之后缓存才失效
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Go_ORM.Tx) error {
+		_, err := Go_ORM.NewUpdater[TestModel](db).Set(Go_ORM.Assign("FirstName", "Jerry")).
+			Where(Go_ORM.C("Id").Eq(1)).Exec(ctx)
+		require.NoError(t, err)
+		// ctx 里面带了事务的查询也不缓存
+		tm, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Limit(2).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*TestModel{{Id: 1, FirstName: "Jerry"}}, tm)
+		assert.Equal(t, 1, c.Len())
+
+		// 回滚了的嵌套事务不会让缓存失效, 提交了的会交给外层事务
+		_ = tx.DoTx(ctx, func(ctx context.Context, tx *Go_ORM.Tx) error {
+			_, err := Go_ORM.NewDeleter[TestModel](tx).Exec(ctx)
+			require.NoError(t, err)
+			return errors.New("rollback")
+		})
+		assert.Equal(t, 1, c.Len())
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.Len())
+	tm, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Jerry"}, tm)
+}
+
+func TestMiddlewareBuilder_UseMaster(t *testing.T) {
+	c := NewLRU(16)
+	db := newCacheDB(t, c)
+	ctx := WithTTL(context.Background(), time.Minute)
+	_, err := Go_ORM.NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+
+	// 缓存里面是旧数据
+	q, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Limit(1).Build()
+	require.NoError(t, err)
+	c.Set(ctx, "test_model", (&Go_ORM.QueryContext{Query: q}).Key(), &TestModel{Id: 1, FirstName: "Old"}, time.Minute)
+	tm, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Old"}, tm)
+
+	// 要求读主库的查询不会读缓存
+	tm, err = Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)).Get(Go_ORM.UseMaster(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, tm)
+}
+
+type Order struct {
+	Id     int64
+	UserId int64
+}
+
+func TestMiddlewareBuilder_Sharding(t *testing.T) {
+	// 只分库, 每个库的 SQL 都是一样的
+	shardingDBs := make(map[string]*sql.DB, 2)
+	for i := int64(0); i < 2; i++ {
+		name := fmt.Sprintf("order_db_%d", i)
+		sdb, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), name+".db"))
+		require.NoError(t, err)
+		_, err = sdb.Exec("CREATE TABLE `order`(id INTEGER PRIMARY KEY, user_id INTEGER)")
+		require.NoError(t, err)
+		_, err = sdb.Exec("INSERT INTO `order`(id, user_id) VALUES (?, ?)", i+100, i)
+		require.NoError(t, err)
+		shardingDBs[name] = sdb
+	}
+	db, err := Go_ORM.NewDB(Go_ORM.DBWithDialect(Go_ORM.SQLite), Go_ORM.DBWithShardingDBs(shardingDBs),
+		Go_ORM.DBWithMiddlewares(NewMiddlewareBuilder(NewLRU(16)).Build()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	_, err = db.Register(&Order{}, Go_ORM.ModelWithSharding("UserId",
+		Go_ORM.HashShardingAlgorithm{DBPattern: "order_db_%d", DBSize: 2}))
+	require.NoError(t, err)
+
+	ctx := WithTTL(context.Background(), time.Minute)
+	want := []*Order{{Id: 100, UserId: 0}, {Id: 101, UserId: 1}}
+	for i := 0; i < 2; i++ {
+		res, err := Go_ORM.NewSelector[Order](db).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, res)
+	}
+}
+
+func TestMiddlewareBuilder_PointerArgs(t *testing.T) {
+	queries := 0
+	db := newCacheDB(t, NewLRU(16), func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			queries++
+			return next(ctx, qc)
+		}
+	})
+	ctx := WithTTL(context.Background(), time.Minute)
+	_, err := Go_ORM.NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+	queries = 0
+
+	// 每次都是新的指针, 但是值一样
+	for i := 0; i < 2; i++ {
+		name := "Tom"
+		tm, err := Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("FirstName").Eq(&name)).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, tm)
+	}
+	assert.Equal(t, 1, queries)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 单位是秒, 和 prometheus 的默认值一致
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram 直方图的快照
+type Histogram struct {
+	// Buckets 每个桶的上界, 单位是秒
+	Buckets []float64
+	// Counts 和 Buckets 一一对应, 是累计值, 即耗时小于等于上界的次数
+	Counts []uint64
+	// Sum 总耗时, 单位是秒
+	Sum   float64
+	Count uint64
+}
+
+type labels struct {
+	table string
+	op    string
+}
+
+// MemoryRecorder 内存实现, 主要用于测试
+type MemoryRecorder struct {
+	buckets []float64
+
+	mutex      sync.Mutex
+	histograms map[labels]*Histogram
+	errors     map[labels]uint64
+}
+
+// NewMemoryRecorder buckets 为空就使用 DefaultBuckets
+func NewMemoryRecorder(buckets ...float64) *MemoryRecorder {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &MemoryRecorder{
+		buckets:    buckets,
+		histograms: make(map[labels]*Histogram, 8),
+		errors:     make(map[labels]uint64, 8),
+	}
+}
+
+func (m *MemoryRecorder) ObserveLatency(table string, op string, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := labels{table: table, op: op}
+	h, ok := m.histograms[key]
+	if !ok {
+		h = &Histogram{
+			Buckets: m.buckets,
+			Counts:  make([]uint64, len(m.buckets)),
+		}
+		m.histograms[key] = h
+	}
+	seconds := duration.Seconds()
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			h.Counts[i]++
+		}
+	}
+	h.Sum += seconds
+	h.Count++
+}
+
+func (m *MemoryRecorder) IncError(table string, op string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.errors[labels{table: table, op: op}]++
+}
+
+// Histogram 返回快照, 没有记录过就返回 false
+func (m *MemoryRecorder) Histogram(table string, op string) (Histogram, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	h, ok := m.histograms[labels{table: table, op: op}]
+	if !ok {
+		return Histogram{}, false
+	}
+	res := *h
+	res.Counts = append([]uint64(nil), h.Counts...)
+	return res, true
+}
+
+func (m *MemoryRecorder) Errors(table string, op string) uint64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.errors[labels{table: table, op: op}]
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"Go_ORM"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Recorder 指标的抽象, 用户可以用自己的指标库实现它, 例如 prometheus 的 HistogramVec 和 CounterVec
+// table 是模型对应的表名, op 是 select, insert, update 或者 delete
+type Recorder interface {
+	// ObserveLatency 记录查询耗时
+	ObserveLatency(table string, op string, duration time.Duration)
+	// IncError 查询出错的时候加一
+	IncError(table string, op string)
+}
+
+type MiddlewareBuilder struct {
+	recorder Recorder
+}
+
+func NewMiddlewareBuilder(recorder Recorder) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		recorder: recorder,
+	}
+}
+
+func (b *MiddlewareBuilder) Build() Go_ORM.Middleware {
+	return func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			start := time.Now()
+			res := next(ctx, qc)
+			table := "unknown"
+			if qc.Model != nil {
+				table = qc.Model.TableName()
+			}
+			op := strings.ToLower(qc.Type)
+			b.recorder.ObserveLatency(table, op, time.Since(start))
+			// 查询不到数据不算错误
+			if res.Err != nil && !errors.Is(res.Err, Go_ORM.ErrNoRows) {
+				b.recorder.IncError(table, op)
+			}
+			return res
+		}
+	}
+}
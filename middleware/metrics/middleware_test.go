@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"Go_ORM"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type TestModel struct {
+	Id int64
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	model, err := Go_ORM.NewRegistry().Register(&TestModel{})
+	require.NoError(t, err)
+	recorder := NewMemoryRecorder()
+	mdl := NewMiddlewareBuilder(recorder).Build()
+
+	handle := func(typ string, err error) {
+		res := mdl(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			return &Go_ORM.QueryResult{Err: err}
+		})(context.Background(), &Go_ORM.QueryContext{
+			Type:  typ,
+			Model: model,
+			Query: &Go_ORM.Query{},
+		})
+		assert.Equal(t, err, res.Err)
+	}
+	handle(Go_ORM.OpSelect, nil)
+	handle(Go_ORM.OpSelect, Go_ORM.ErrNoRows)
+	handle(Go_ORM.OpSelect, errors.New("select error"))
+	handle(Go_ORM.OpDelete, errors.New("delete error"))
+
+	h, ok := recorder.Histogram("test_model", "select")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), h.Count)
+	assert.Equal(t, 2, len(recorder.histograms))
+	assert.Equal(t, uint64(1), recorder.Errors("test_model", "select"))
+	assert.Equal(t, uint64(1), recorder.Errors("test_model", "delete"))
+	assert.Equal(t, uint64(0), recorder.Errors("test_model", "update"))
+	_, ok = recorder.Histogram("test_model", "update")
+	assert.False(t, ok)
+}
+
+func TestMemoryRecorder_ObserveLatency(t *testing.T) {
+	recorder := NewMemoryRecorder(1, 0.1, 0.5)
+	recorder.ObserveLatency("user", "select", time.Millisecond*50)
+	recorder.ObserveLatency("user", "select", time.Millisecond*200)
+	recorder.ObserveLatency("user", "select", time.Second*2)
+
+	h, ok := recorder.Histogram("user", "select")
+	assert.True(t, ok)
+	assert.Equal(t, []float64{0.1, 0.5, 1}, h.Buckets)
+	// 累计值
+	assert.Equal(t, []uint64{1, 2, 2}, h.Counts)
+	assert.Equal(t, uint64(3), h.Count)
+	assert.InDelta(t, 2.25, h.Sum, 0.0001)
+}
@@ -0,0 +1,58 @@
+package opentelemetry
+
+import (
+	"Go_ORM"
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "Go_ORM/middleware/opentelemetry"
+
+type MiddlewareBuilder struct {
+	tracer trace.Tracer
+}
+
+// NewMiddlewareBuilder tracer 为 nil 就使用全局的 TracerProvider
+func NewMiddlewareBuilder(tracer trace.Tracer) *MiddlewareBuilder {
+	if tracer == nil {
+		tracer = otel.GetTracerProvider().Tracer(instrumentationName)
+	}
+	return &MiddlewareBuilder{
+		tracer: tracer,
+	}
+}
+
+func (b *MiddlewareBuilder) Build() Go_ORM.Middleware {
+	return func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			table := "unknown"
+			if qc.Model != nil {
+				table = qc.Model.TableName()
+			}
+			// span 的名字是 "SELECT test_model" 这种形式
+			ctx, span := b.tracer.Start(ctx, qc.Type+" "+table,
+				trace.WithSpanKind(trace.SpanKindClient))
+			defer span.End()
+
+			// 带着 span 的 ctx 会一路传到 driver
+			res := next(ctx, qc)
+
+			span.SetAttributes(
+				semconv.DBSQLTableKey.String(table),
+				semconv.DBOperationKey.String(qc.Type),
+				// 以执行的查询为准, 因为内层的 Middleware 可能篡改了查询
+				semconv.DBStatementKey.String(qc.Query.SQL),
+			)
+			// 查询不到数据不算错误
+			if res.Err != nil && !errors.Is(res.Err, Go_ORM.ErrNoRows) {
+				span.RecordError(res.Err)
+				span.SetStatus(codes.Error, res.Err.Error())
+			}
+			return res
+		}
+	}
+}
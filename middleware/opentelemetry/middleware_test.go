@@ -0,0 +1,97 @@
+package opentelemetry
+
+import (
+	"Go_ORM"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+type TestModel struct {
+	Id int64
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	model, err := Go_ORM.NewRegistry().Register(&TestModel{})
+	require.NoError(t, err)
+	queryErr := errors.New("query error")
+	testCases := []struct {
+		name string
+		typ  string
+		sql  string
+		err  error
+
+		wantName   string
+		wantStatus codes.Code
+		wantEvents int
+	}{
+		{
+			name:       "select",
+			typ:        Go_ORM.OpSelect,
+			sql:        "SELECT * FROM `test_model`;",
+			wantName:   "SELECT test_model",
+			wantStatus: codes.Unset,
+		},
+
+		{
+			name:       "no rows",
+			typ:        Go_ORM.OpSelect,
+			sql:        "SELECT * FROM `test_model`;",
+			err:        Go_ORM.ErrNoRows,
+			wantName:   "SELECT test_model",
+			wantStatus: codes.Unset,
+		},
+
+		{
+			name:       "error",
+			typ:        Go_ORM.OpDelete,
+			sql:        "DELETE FROM `test_model`;",
+			err:        queryErr,
+			wantName:   "DELETE test_model",
+			wantStatus: codes.Error,
+			wantEvents: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			mdl := NewMiddlewareBuilder(tp.Tracer("test")).Build()
+
+			var spanCtx trace.SpanContext
+			res := mdl(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+				// ctx 要传下去
+				spanCtx = trace.SpanContextFromContext(ctx)
+				return &Go_ORM.QueryResult{Err: tc.err}
+			})(context.Background(), &Go_ORM.QueryContext{
+				Type:  tc.typ,
+				Model: model,
+				Query: &Go_ORM.Query{SQL: tc.sql},
+			})
+			assert.Equal(t, tc.err, res.Err)
+
+			spans := sr.Ended()
+			require.Equal(t, 1, len(spans))
+			span := spans[0]
+			assert.True(t, spanCtx.IsValid())
+			assert.Equal(t, span.SpanContext().SpanID(), spanCtx.SpanID())
+			assert.Equal(t, tc.wantName, span.Name())
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			assert.Equal(t, []attribute.KeyValue{
+				attribute.String("db.sql.table", "test_model"),
+				attribute.String("db.operation", tc.typ),
+				attribute.String("db.statement", tc.sql),
+			}, span.Attributes())
+			assert.Equal(t, tc.wantStatus, span.Status().Code)
+			assert.Equal(t, tc.wantEvents, len(span.Events()))
+		})
+	}
+}
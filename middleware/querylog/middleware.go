@@ -0,0 +1,127 @@
+package querylog
+
+import (
+	"Go_ORM"
+	"context"
+	"database/sql"
+	"log"
+	"reflect"
+	"time"
+)
+
+// Entry 一条查询日志
+type Entry struct {
+	// Type SELECT, INSERT, UPDATE 或者 DELETE
+	Type string
+	SQL  string
+	// Args 开启了 RedactArgs 之后为 nil, 只保留 ArgCount
+	Args     []any
+	ArgCount int
+	Duration time.Duration
+	// RowsAffected 对于 SELECT 是返回的行数
+	RowsAffected int64
+	Err          error
+	// Slow 超过了 SlowThreshold
+	Slow bool
+}
+
+// Logger 用户可以接入自己的结构化日志
+type Logger interface {
+	Log(ctx context.Context, entry Entry)
+}
+
+type LoggerFunc func(ctx context.Context, entry Entry)
+
+func (f LoggerFunc) Log(ctx context.Context, entry Entry) {
+	f(ctx, entry)
+}
+
+type MiddlewareBuilder struct {
+	logger        Logger
+	redactArgs    bool
+	slowThreshold time.Duration
+}
+
+// NewMiddlewareBuilder 默认使用标准库的 log 输出
+func NewMiddlewareBuilder() *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		logger: LoggerFunc(func(ctx context.Context, entry Entry) {
+			log.Printf("orm: type=%s sql=%s args=%v arg_count=%d duration=%s rows=%d slow=%t err=%v",
+				entry.Type, entry.SQL, entry.Args, entry.ArgCount, entry.Duration,
+				entry.RowsAffected, entry.Slow, entry.Err)
+		}),
+	}
+}
+
+func (b *MiddlewareBuilder) Logger(logger Logger) *MiddlewareBuilder {
+	b.logger = logger
+	return b
+}
+
+// RedactArgs 不输出参数, 只输出参数个数, 避免敏感数据进入日志
+func (b *MiddlewareBuilder) RedactArgs() *MiddlewareBuilder {
+	b.redactArgs = true
+	return b
+}
+
+// SlowThreshold 只输出耗时达到 threshold 的查询, 也就是慢查询日志
+func (b *MiddlewareBuilder) SlowThreshold(threshold time.Duration) *MiddlewareBuilder {
+	b.slowThreshold = threshold
+	return b
+}
+
+func (b *MiddlewareBuilder) Build() Go_ORM.Middleware {
+	return func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			start := time.Now()
+			res := next(ctx, qc)
+			duration := time.Since(start)
+			slow := b.slowThreshold > 0 && duration >= b.slowThreshold
+			if b.slowThreshold > 0 && !slow {
+				return res
+			}
+
+			// 以执行的查询为准, 因为别的 Middleware 可能篡改了查询
+			entry := Entry{
+				Type:         qc.Type,
+				SQL:          qc.Query.SQL,
+				ArgCount:     len(qc.Query.Args),
+				Duration:     duration,
+				RowsAffected: rowsAffected(res),
+				Err:          res.Err,
+				Slow:         slow,
+			}
+			if !b.redactArgs {
+				entry.Args = qc.Query.Args
+			}
+			b.logger.Log(ctx, entry)
+			return res
+		}
+	}
+}
+
+func rowsAffected(res *Go_ORM.QueryResult) int64 {
+	if res.Err != nil || res.Result == nil {
+		return 0
+	}
+	if r, ok := res.Result.(sql.Result); ok {
+		n, err := r.RowsAffected()
+		if err != nil {
+			return 0
+		}
+		return n
+	}
+	// GetMulti 返回的是切片, Get 返回的是单个指针
+	val := reflect.ValueOf(res.Result)
+	switch val.Kind() {
+	case reflect.Slice:
+		return int64(val.Len())
+	case reflect.Pointer:
+		if val.IsNil() {
+			return 0
+		}
+		return 1
+	default:
+		return 0
+	}
+}
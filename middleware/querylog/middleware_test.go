@@ -0,0 +1,134 @@
+package querylog
+
+import (
+	"Go_ORM"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type mockResult int64
+
+func (m mockResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (m mockResult) RowsAffected() (int64, error) {
+	return int64(m), nil
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	queryErr := errors.New("query error")
+	testCases := []struct {
+		name    string
+		builder *MiddlewareBuilder
+		qc      *Go_ORM.QueryContext
+		res     *Go_ORM.QueryResult
+		sleep   time.Duration
+
+		wantEntries []Entry
+	}{
+		{
+			name:    "exec",
+			builder: NewMiddlewareBuilder(),
+			qc: &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpUpdate,
+				Query: &Go_ORM.Query{SQL: "UPDATE `test_model` SET `age`=?;", Args: []any{18}},
+			},
+			res: &Go_ORM.QueryResult{Result: mockResult(3)},
+			wantEntries: []Entry{
+				{
+					Type:         Go_ORM.OpUpdate,
+					SQL:          "UPDATE `test_model` SET `age`=?;",
+					Args:         []any{18},
+					ArgCount:     1,
+					RowsAffected: 3,
+				},
+			},
+		},
+
+		{
+			name:    "redact args",
+			builder: NewMiddlewareBuilder().RedactArgs(),
+			qc: &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpSelect,
+				Query: &Go_ORM.Query{SQL: "SELECT * FROM `test_model` WHERE `first_name` = ?;", Args: []any{"Tom"}},
+			},
+			res: &Go_ORM.QueryResult{Result: []*struct{}{{}, {}}},
+			wantEntries: []Entry{
+				{
+					Type:         Go_ORM.OpSelect,
+					SQL:          "SELECT * FROM `test_model` WHERE `first_name` = ?;",
+					ArgCount:     1,
+					RowsAffected: 2,
+				},
+			},
+		},
+
+		{
+			name:    "error",
+			builder: NewMiddlewareBuilder(),
+			qc: &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpSelect,
+				Query: &Go_ORM.Query{SQL: "SELECT * FROM `test_model`;"},
+			},
+			res: &Go_ORM.QueryResult{Err: queryErr},
+			wantEntries: []Entry{
+				{
+					Type: Go_ORM.OpSelect,
+					SQL:  "SELECT * FROM `test_model`;",
+					Err:  queryErr,
+				},
+			},
+		},
+
+		{
+			name:    "fast query",
+			builder: NewMiddlewareBuilder().SlowThreshold(time.Hour),
+			qc: &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpSelect,
+				Query: &Go_ORM.Query{SQL: "SELECT * FROM `test_model`;"},
+			},
+			res: &Go_ORM.QueryResult{Result: &struct{}{}},
+		},
+
+		{
+			name:    "slow query",
+			builder: NewMiddlewareBuilder().SlowThreshold(time.Millisecond),
+			qc: &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpSelect,
+				Query: &Go_ORM.Query{SQL: "SELECT * FROM `test_model`;"},
+			},
+			res:   &Go_ORM.QueryResult{Result: &struct{}{}},
+			sleep: time.Millisecond * 5,
+			wantEntries: []Entry{
+				{
+					Type:         Go_ORM.OpSelect,
+					SQL:          "SELECT * FROM `test_model`;",
+					RowsAffected: 1,
+					Slow:         true,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var entries []Entry
+			mdl := tc.builder.Logger(LoggerFunc(func(ctx context.Context, entry Entry) {
+				assert.True(t, entry.Duration >= tc.sleep)
+				// 耗时不固定, 不参与比较
+				entry.Duration = 0
+				entries = append(entries, entry)
+			})).Build()
+			res := mdl(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+				time.Sleep(tc.sleep)
+				return tc.res
+			})(context.Background(), tc.qc)
+			assert.Equal(t, tc.res, res)
+			assert.Equal(t, tc.wantEntries, entries)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package safedml
+
+import (
+	"Go_ORM"
+	"Go_ORM/internal/errs"
+	"context"
+	"strings"
+)
+
+// ErrUnsafeDML 被拦截的查询都会返回包装了它的错误, 用 errors.Is 判断
+var ErrUnsafeDML = errs.ErrUnsafeDML
+
+// MiddlewareBuilder 拦截危险的查询
+// UPDATE 和 DELETE 必须带 WHERE
+// 大表的 SELECT 必须带 WHERE 或者 LIMIT
+type MiddlewareBuilder struct {
+	largeTables map[string]struct{}
+}
+
+func NewMiddlewareBuilder() *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		largeTables: map[string]struct{}{},
+	}
+}
+
+// LargeTables 标记大表, 传入的是表名
+func (b *MiddlewareBuilder) LargeTables(tables ...string) *MiddlewareBuilder {
+	for _, tbl := range tables {
+		b.largeTables[tbl] = struct{}{}
+	}
+	return b
+}
+
+func (b *MiddlewareBuilder) Build() Go_ORM.Middleware {
+	return func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			if err := b.check(qc); err != nil {
+				return &Go_ORM.QueryResult{Err: err}
+			}
+			return next(ctx, qc)
+		}
+	}
+}
+
+// check 检查的是构造好的 SQL, 这样外层 Middleware 篡改过的查询也能检查到
+func (b *MiddlewareBuilder) check(qc *Go_ORM.QueryContext) error {
+	table := ""
+	if qc.Model != nil {
+		table = qc.Model.TableName()
+	}
+	switch qc.Type {
+	case Go_ORM.OpUpdate, Go_ORM.OpDelete:
+		if !hasKeyword(qc.Query.SQL, "WHERE") {
+			return errs.NewErrUnsafeDML(qc.Type, table, "没有 WHERE")
+		}
+	case Go_ORM.OpSelect:
+		if _, ok := b.largeTables[table]; !ok {
+			return nil
+		}
+		if !hasKeyword(qc.Query.SQL, "WHERE") && !hasKeyword(qc.Query.SQL, "LIMIT") {
+			return errs.NewErrUnsafeDML(qc.Type, table, "大表没有 WHERE 或者 LIMIT")
+		}
+	}
+	return nil
+}
+
+// hasKeyword 判断 SQL 里面有没有关键字
+// 反引号、单引号和双引号里面的内容会被跳过, 所以列名叫 where 也不会误判
+func hasKeyword(query string, keyword string) bool {
+	var quote byte
+	start := -1
+	for i := 0; i <= len(query); i++ {
+		var c byte
+		if i < len(query) {
+			c = query[i]
+		}
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if isWordByte(c) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && strings.EqualFold(query[start:i], keyword) {
+			return true
+		}
+		start = -1
+		if c == '`' || c == '\'' || c == '"' {
+			quote = c
+		}
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,136 @@
+package safedml
+
+import (
+	"Go_ORM"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type TestModel struct {
+	Id    int64
+	Where string
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	db, err := Go_ORM.NewDB()
+	require.NoError(t, err)
+	model, err := Go_ORM.NewRegistry().Register(&TestModel{})
+	require.NoError(t, err)
+	testCases := []struct {
+		name        string
+		typ         string
+		builder     Go_ORM.QueryBuilder
+		largeTables []string
+
+		wantErr error
+	}{
+		{
+			name:    "delete without where",
+			typ:     Go_ORM.OpDelete,
+			builder: Go_ORM.NewDeleter[TestModel](db),
+			wantErr: errors.New("orm: 不安全的查询: DELETE test_model 没有 WHERE"),
+		},
+
+		{
+			name:    "delete with where",
+			typ:     Go_ORM.OpDelete,
+			builder: Go_ORM.NewDeleter[TestModel](db).Where(Go_ORM.C("Id").Eq(1)),
+		},
+
+		{
+			name:    "update without where",
+			typ:     Go_ORM.OpUpdate,
+			builder: Go_ORM.NewUpdater[TestModel](db).Set(Go_ORM.Assign("Where", "abc")),
+			wantErr: errors.New("orm: 不安全的查询: UPDATE test_model 没有 WHERE"),
+		},
+
+		{
+			name: "update with where",
+			typ:  Go_ORM.OpUpdate,
+			builder: Go_ORM.NewUpdater[TestModel](db).Set(Go_ORM.Assign("Where", "abc")).
+				Where(Go_ORM.C("Where").Eq("WHERE")),
+		},
+
+		{
+			name:    "select small table",
+			typ:     Go_ORM.OpSelect,
+			builder: Go_ORM.NewSelector[TestModel](db),
+		},
+
+		{
+			name:        "select large table",
+			typ:         Go_ORM.OpSelect,
+			builder:     Go_ORM.NewSelector[TestModel](db),
+			largeTables: []string{"test_model"},
+			wantErr:     errors.New("orm: 不安全的查询: SELECT test_model 大表没有 WHERE 或者 LIMIT"),
+		},
+
+		{
+			name:        "select large table with limit",
+			typ:         Go_ORM.OpSelect,
+			builder:     Go_ORM.NewSelector[TestModel](db).Limit(10),
+			largeTables: []string{"test_model"},
+		},
+
+		{
+			name:        "select large table with where",
+			typ:         Go_ORM.OpSelect,
+			builder:     Go_ORM.NewSelector[TestModel](db).Where(Go_ORM.C("Id").Eq(1)),
+			largeTables: []string{"test_model"},
+		},
+
+		{
+			name:    "insert",
+			typ:     Go_ORM.OpInsert,
+			builder: Go_ORM.NewInserter[TestModel](db).Values(&TestModel{}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			require.NoError(t, err)
+			mdl := NewMiddlewareBuilder().LargeTables(tc.largeTables...).Build()
+			called := false
+			res := mdl(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+				called = true
+				return &Go_ORM.QueryResult{}
+			})(context.Background(), &Go_ORM.QueryContext{
+				Type:    tc.typ,
+				Builder: tc.builder,
+				Query:   q,
+				Model:   model,
+			})
+			if tc.wantErr == nil {
+				assert.NoError(t, res.Err)
+				assert.True(t, called)
+				return
+			}
+			assert.EqualError(t, res.Err, tc.wantErr.Error())
+			assert.True(t, errors.Is(res.Err, ErrUnsafeDML))
+			assert.False(t, called)
+		})
+	}
+}
+
+func TestHasKeyword(t *testing.T) {
+	testCases := []struct {
+		query string
+		want  bool
+	}{
+		{query: "DELETE FROM `t` WHERE `id` = ?;", want: true},
+		{query: "delete from t where id = 1", want: true},
+		{query: "UPDATE `t` SET `where`=?;", want: false},
+		{query: "UPDATE `t` SET `a`='WHERE';", want: false},
+		{query: "DELETE FROM `t_where`;", want: false},
+		{query: "DELETE FROM t WHERE", want: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.want, hasKeyword(tc.query, "WHERE"))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"Go_ORM"
+	"Go_ORM/internal/copier"
+	"context"
+	"golang.org/x/sync/singleflight"
+	"time"
+)
+
+// MiddlewareBuilder 同样的 SELECT 同时只有一个会真的查询数据库, 其它的等待并共享结果
+// 事务里面的查询能看到未提交的数据, 所以事务里面的查询不会被合并, 要求读主库的查询也不会被合并
+type MiddlewareBuilder struct {
+	group *singleflight.Group
+}
+
+func NewMiddlewareBuilder() *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		group: &singleflight.Group{},
+	}
+}
+
+func (b *MiddlewareBuilder) Build() Go_ORM.Middleware {
+	return func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			if qc.Type != Go_ORM.OpSelect {
+				return next(ctx, qc)
+			}
+			if _, ok := Go_ORM.TxFromContext(ctx); ok {
+				return next(ctx, qc)
+			}
+			if _, ok := qc.Tx(ctx); ok {
+				return next(ctx, qc)
+			}
+			// 要求读主库的查询不能加入已经在从库上执行的查询
+			if Go_ORM.IsUseMaster(ctx) {
+				return next(ctx, qc)
+			}
+			// Get 和 GetMulti 的结果类型不同, 不能共享, Key 会区分开
+			key := qc.Key()
+			// 共享的查询不能因为发起者取消了就中止, 不然其它调用者都会拿到 context.Canceled
+			sharedCtx := detachedContext{Context: ctx}
+			ch := b.group.DoChan(key, func() (any, error) {
+				res := next(sharedCtx, qc)
+				return res.Result, res.Err
+			})
+			// 自己的 ctx 过期了就不等了, 查询还会继续, 结果留给其它调用者
+			select {
+			case <-ctx.Done():
+				return &Go_ORM.QueryResult{Err: ctx.Err()}
+			case r := <-ch:
+				val := r.Val
+				if r.Shared {
+					// 每个调用者拿到的都是自己的副本
+					val = copier.DeepCopy(val)
+				}
+				return &Go_ORM.QueryResult{Result: val, Err: r.Err}
+			}
+		}
+	}
+}
+
+// detachedContext 保留 ctx 里面的值, 但是不会过期, 也不会被取消
+// Go 1.21 之后可以用 context.WithoutCancel
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
@@ -0,0 +1,269 @@
+package singleflight
+
+import (
+	"Go_ORM"
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestModel struct {
+	Id int64
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mdl := NewMiddlewareBuilder().Build()
+	handler := mdl(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &Go_ORM.QueryResult{Result: &TestModel{Id: qc.Query.Args[0].(int64)}}
+	})
+
+	const n = 100
+	var wg sync.WaitGroup
+	results := make([]*Go_ORM.QueryResult, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 一半查询 1, 一半查询 2
+			id := int64(i%2 + 1)
+			results[i] = handler(context.Background(), &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpSelect,
+				Query: &Go_ORM.Query{SQL: "SELECT * FROM `test_model` WHERE `id` = ?;", Args: []any{id}},
+			})
+		}(i)
+	}
+	// 等所有的 goroutine 都在等待结果
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	for i, res := range results {
+		assert.NoError(t, res.Err)
+		assert.Equal(t, &TestModel{Id: int64(i%2 + 1)}, res.Result)
+	}
+	// 每个人拿到的都是自己的副本
+	assert.NotSame(t, results[0].Result, results[2].Result)
+}
+
+func TestMiddlewareBuilder_Skip(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  context.Context
+		typ  string
+	}{
+		{
+			name: "exec",
+			ctx:  context.Background(),
+			typ:  Go_ORM.OpUpdate,
+		},
+		{
+			name: "tx",
+			ctx:  Go_ORM.ContextWithTx(context.Background(), &Go_ORM.Tx{}),
+			typ:  Go_ORM.OpSelect,
+		},
+		{
+			name: "use master",
+			ctx:  Go_ORM.UseMaster(context.Background()),
+			typ:  Go_ORM.OpSelect,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			release := make(chan struct{})
+			handler := NewMiddlewareBuilder().Build()(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return &Go_ORM.QueryResult{}
+			})
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					handler(tc.ctx, &Go_ORM.QueryContext{
+						Type:  tc.typ,
+						Query: &Go_ORM.Query{SQL: "SQL"},
+					})
+				}()
+			}
+			time.Sleep(time.Millisecond * 100)
+			close(release)
+			wg.Wait()
+			assert.Equal(t, int32(10), atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestMiddlewareBuilder_ContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	handler := NewMiddlewareBuilder().Build()(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+		<-release
+		return &Go_ORM.QueryResult{}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	res := handler(ctx, &Go_ORM.QueryContext{
+		Type:  Go_ORM.OpSelect,
+		Query: &Go_ORM.Query{SQL: "SQL"},
+	})
+	assert.Equal(t, context.DeadlineExceeded, res.Err)
+}
+
+func TestMiddlewareBuilder_LeaderCanceled(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := NewMiddlewareBuilder().Build()(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+		close(started)
+		<-release
+		// 模拟驱动: ctx 取消了查询就会中止
+		if ctx.Err() != nil {
+			return &Go_ORM.QueryResult{Err: ctx.Err()}
+		}
+		return &Go_ORM.QueryResult{Result: &TestModel{Id: 1}}
+	})
+	qc := &Go_ORM.QueryContext{
+		Type:  Go_ORM.OpSelect,
+		Query: &Go_ORM.Query{SQL: "SQL"},
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderRes := make(chan *Go_ORM.QueryResult, 1)
+	go func() {
+		leaderRes <- handler(leaderCtx, qc)
+	}()
+	<-started
+	followerRes := make(chan *Go_ORM.QueryResult, 1)
+	go func() {
+		followerRes <- handler(context.Background(), qc)
+	}()
+	time.Sleep(time.Millisecond * 50)
+
+	cancel()
+	assert.Equal(t, context.Canceled, (<-leaderRes).Err)
+	close(release)
+	res := <-followerRes
+	assert.NoError(t, res.Err)
+	assert.Equal(t, &TestModel{Id: 1}, res.Result)
+}
+
+func TestMiddlewareBuilder_GetAndGetMulti(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	_, err = sqlDB.Exec("CREATE TABLE test_model(id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	_, err = sqlDB.Exec("INSERT INTO test_model(id) VALUES (1)")
+	require.NoError(t, err)
+
+	// 让两个查询同时停在 singleflight 里面, 被合并的话只会有一个到这里
+	arrived := make(chan struct{}, 2)
+	barrier := func(next Go_ORM.Handler) Go_ORM.Handler {
+		return func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+			arrived <- struct{}{}
+			deadline := time.Now().Add(time.Millisecond * 500)
+			for len(arrived) < 2 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			return next(ctx, qc)
+		}
+	}
+	db, err := Go_ORM.OpenDB(sqlDB, Go_ORM.DBWithDialect(Go_ORM.SQLite),
+		Go_ORM.DBWithMiddlewares(NewMiddlewareBuilder().Build(), barrier))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Limit(1) 的 GetMulti 和 Get 的 SQL 是一样的
+	ctx := context.Background()
+	var tm *TestModel
+	var getErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tm, getErr = Go_ORM.NewSelector[TestModel](db).Get(ctx)
+	}()
+	tms, err := Go_ORM.NewSelector[TestModel](db).Limit(1).GetMulti(ctx)
+	<-done
+	require.NoError(t, err)
+	require.NoError(t, getErr)
+	assert.Equal(t, []*TestModel{{Id: 1}}, tms)
+	assert.Equal(t, &TestModel{Id: 1}, tm)
+}
+
+func TestMiddlewareBuilder_ShardingDB(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mdl := NewMiddlewareBuilder().Build()
+	handler := mdl(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &Go_ORM.QueryResult{Result: qc.Query.DB}
+	})
+
+	// SQL 一样, 但是在不同的分库上执行
+	dbs := []string{"order_db_0", "order_db_1"}
+	var wg sync.WaitGroup
+	results := make([]*Go_ORM.QueryResult, len(dbs))
+	for i, name := range dbs {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = handler(context.Background(), &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpSelect,
+				Query: &Go_ORM.Query{SQL: "SELECT * FROM `order`;", DB: name},
+			})
+		}(i, name)
+	}
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	for i, res := range results {
+		assert.Equal(t, dbs[i], res.Result)
+	}
+}
+
+func TestMiddlewareBuilder_PointerArgs(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mdl := NewMiddlewareBuilder().Build()
+	handler := mdl(func(ctx context.Context, qc *Go_ORM.QueryContext) *Go_ORM.QueryResult {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &Go_ORM.QueryResult{Result: &TestModel{Id: 1}}
+	})
+
+	// 每个调用者的参数都是新的指针, 但是值一样
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := sql.NullString{String: "Tom", Valid: true}
+			res := handler(context.Background(), &Go_ORM.QueryContext{
+				Type:  Go_ORM.OpSelect,
+				Query: &Go_ORM.Query{SQL: "SELECT * FROM `test_model` WHERE `last_name` = ?;", Args: []any{&name}},
+			})
+			assert.NoError(t, res.Err)
+		}()
+	}
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
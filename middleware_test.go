@@ -0,0 +1,164 @@
+package Go_ORM
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var logs []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, qc *QueryContext) *QueryResult {
+				logs = append(logs, name+" "+qc.Type+" "+qc.Query.SQL)
+				res := next(ctx, qc)
+				logs = append(logs, name+" done")
+				return res
+			}
+		}
+	}
+	db := newSQLiteDB(t, DBWithMiddlewares(record("first"), record("second")))
+	ctx := context.Background()
+
+	_, err := NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+	_, err = NewSelector[TestModel](db).Get(ctx)
+	require.NoError(t, err)
+	// 事务里面也会经过 Middleware
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		_, err := NewDeleter[TestModel](tx).Where(C("Id").Eq(1)).Exec(ctx)
+		return err
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"first INSERT INSERT INTO `test_model`(`id`,`age`,`first_name`,`last_name`) VALUES (?,?,?,?);",
+		"second INSERT INSERT INTO `test_model`(`id`,`age`,`first_name`,`last_name`) VALUES (?,?,?,?);",
+		"second done",
+		"first done",
+		"first SELECT SELECT * FROM `test_model` LIMIT ?;",
+		"second SELECT SELECT * FROM `test_model` LIMIT ?;",
+		"second done",
+		"first done",
+		"first DELETE DELETE FROM `test_model` WHERE `id` = ?;",
+		"second DELETE DELETE FROM `test_model` WHERE `id` = ?;",
+		"second done",
+		"first done",
+	}, logs)
+}
+
+func TestMiddleware_Query(t *testing.T) {
+	mdlErr := errors.New("mdl error")
+	testCases := []struct {
+		name string
+		mdl  Middleware
+
+		wantRes []*TestModel
+		wantErr error
+	}{
+		{
+			// 篡改查询
+			name: "rewrite query",
+			mdl: func(next Handler) Handler {
+				return func(ctx context.Context, qc *QueryContext) *QueryResult {
+					qc.Query = &Query{
+						SQL:  "SELECT * FROM `test_model` WHERE `id` = ?;",
+						Args: []any{2},
+					}
+					return next(ctx, qc)
+				}
+			},
+			wantRes: []*TestModel{{Id: 2, FirstName: "Jerry"}},
+		},
+
+		{
+			// 直接返回, 不查询数据库
+			name: "short circuit",
+			mdl: func(next Handler) Handler {
+				return func(ctx context.Context, qc *QueryContext) *QueryResult {
+					return &QueryResult{Err: mdlErr}
+				}
+			},
+			wantErr: mdlErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newSQLiteDB(t)
+			ctx := context.Background()
+			_, err := NewInserter[TestModel](db).Values(
+				&TestModel{Id: 1, FirstName: "Tom"},
+				&TestModel{Id: 2, FirstName: "Jerry"},
+			).Exec(ctx)
+			require.NoError(t, err)
+			db.mdls = []Middleware{tc.mdl}
+
+			res, err := NewSelector[TestModel](db).GetMulti(ctx)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func TestQueryContext_Key(t *testing.T) {
+	const query = "SELECT * FROM `test_model` WHERE `last_name` = ?;"
+	newQC := func(multi bool, db string, args ...any) *QueryContext {
+		return &QueryContext{Query: &Query{SQL: query, Args: args, DB: db}, multi: multi}
+	}
+	var nilName *sql.NullString
+	testCases := []struct {
+		name  string
+		left  *QueryContext
+		right *QueryContext
+
+		wantEqual bool
+	}{
+		{
+			// 指针参数按照指向的值计算
+			name:      "pointer",
+			left:      newQC(false, "", &sql.NullString{String: "Tom", Valid: true}),
+			right:     newQC(false, "", &sql.NullString{String: "Tom", Valid: true}),
+			wantEqual: true,
+		},
+		{
+			name:  "pointer different value",
+			left:  newQC(false, "", &sql.NullString{String: "Tom", Valid: true}),
+			right: newQC(false, "", &sql.NullString{String: "Jerry", Valid: true}),
+		},
+		{
+			name:      "nil pointer",
+			left:      newQC(false, "", nilName),
+			right:     newQC(false, "", nil),
+			wantEqual: true,
+		},
+		{
+			name:  "multi",
+			left:  newQC(false, "", 1),
+			right: newQC(true, "", 1),
+		},
+		{
+			name:  "sharding db",
+			left:  newQC(false, "order_db_0", 1),
+			right: newQC(false, "order_db_1", 1),
+		},
+		{
+			name:  "string and int",
+			left:  newQC(false, "", "1"),
+			right: newQC(false, "", 1),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantEqual, tc.left.Key() == tc.right.Key())
+		})
+	}
+}
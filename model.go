@@ -2,35 +2,200 @@ package Go_ORM
 
 import (
 	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
-	"unicode"
+	"time"
 )
 
 const (
 	tagTestColumn = "column"
+	tagSize       = "size"
+	tagDefault    = "default"
+	// tagPrefix 展开结构体的时候, 给里面的列名加上前缀
+	tagPrefix = "prefix"
+
+	// 下面是不需要值的标签
+	tagPrimaryKey    = "pk"
+	tagAutoIncrement = "autoincr"
+	tagNotNull       = "notnull"
+	tagUnique        = "unique"
+	// tagEmbedded 把非匿名的结构体字段展开到模型里面
+	tagEmbedded = "embedded"
+
+	// 自动维护的时间, 可以带上 =milli 表示整数类型的字段存的是毫秒
+	tagAutoCreateTime = "autoCreateTime"
+	tagAutoUpdateTime = "autoUpdateTime"
+
+	// tagIgnore orm:"-" 表示这个字段不映射到任何列
+	tagIgnore = "-"
+
+	// conventionPrimaryKey 没有声明主键的时候, 叫这个名字的字段就是主键
+	conventionPrimaryKey = "Id"
 )
 
+// tagsWithValue 这些标签必须是 key=value 的形式
+var tagsWithValue = map[string]struct{}{
+	tagTestColumn: {},
+	tagSize:       {},
+	tagDefault:    {},
+	tagPrefix:     {},
+}
+
+// tagsWithoutValue 这些标签不需要值, 自动时间的标签可以带上 =milli
+// 不在这两个集合里面的标签都是写错了, 例如 primary_key 或者 colum
+var tagsWithoutValue = map[string]struct{}{
+	tagPrimaryKey:     {},
+	tagAutoIncrement:  {},
+	tagNotNull:        {},
+	tagUnique:         {},
+	tagEmbedded:       {},
+	tagAutoCreateTime: {},
+	tagAutoUpdateTime: {},
+}
+
 // Registry 元数据注册中心的抽象
 type Registry interface {
 	// Get 查找元数据
 	Get(val any) (*Model, error)
 	// Register 注册一个模型
 	Register(val any, opts ...ModelOpt) (*Model, error)
+	// RegisterAll 注册多个模型, 返回的错误包含了所有失败的模型
+	RegisterAll(vals ...any) error
+	// Models 已经注册的模型, 按照表名排序
+	Models() []*Model
 }
 
 type Model struct {
 	// tableName 结构体对应的表名
 	tableName string
 	fileMap   map[string]*Field
+	// columnMap 列名到字段的映射, 处理结果集的时候使用
+	columnMap map[string]*Field
+	// fields 按照字段定义的顺序, 组合的结构体已经展开
+	fields []*Field
+	// primaryKeys 主键的字段名, 按照字段定义的顺序, 多个就是联合主键
+	primaryKeys []string
+	// sharding 为 nil 表示不分片
+	sharding *shardingConfig
+	// tableResolver 为 nil 表示总是使用 tableName
+	tableResolver TableNameResolver
+	hooks         hooks
+}
+
+// TableName 表名, 主要给 Middleware 之类的扩展使用
+func (m *Model) TableName() string {
+	return m.tableName
+}
+
+// PrimaryKeys 主键的字段名
+func (m *Model) PrimaryKeys() []string {
+	return m.primaryKeys
+}
+
+// Fields 按照字段定义的顺序返回全部字段, 不要修改返回的字段
+func (m *Model) Fields() []*Field {
+	res := make([]*Field, len(m.fields))
+	copy(res, m.fields)
+	return res
+}
+
+// FieldByColumn 根据列名找到字段
+func (m *Model) FieldByColumn(colName string) (*Field, bool) {
+	fd, ok := m.columnMap[colName]
+	return fd, ok
 }
 
 type ModelOpt func(m *Model) error
 
 // Field 字段
 type Field struct {
+	// name 字段名, 展开的非匿名结构体里面的字段是 Addr.City 的形式
+	name    string
 	colName string
+	typ     reflect.Type
+	// index 字段在结构体里面的路径, 用于 reflect.Value.FieldByIndex
+	index []int
+	// offset 字段相对于结构体起始地址的偏移量, 展开的结构体也算上了
+	offset uintptr
+
+	primaryKey    bool
+	autoIncrement bool
+	notNull       bool
+	unique        bool
+	// size 为 0 表示没有设置
+	size int
+	// defaultVal 在 hasDefault 为 true 的时候才有意义
+	defaultVal string
+	hasDefault bool
+
+	autoCreateTime bool
+	autoUpdateTime bool
+	// autoTimeMilli 整数类型的自动时间存的是毫秒, 否则是秒
+	autoTimeMilli bool
+}
+
+// Name 字段名, 展开的非匿名结构体里面的字段是 Addr.City 的形式
+func (f *Field) Name() string {
+	return f.name
+}
+
+func (f *Field) Type() reflect.Type {
+	return f.typ
+}
+
+func (f *Field) Index() []int {
+	res := make([]int, len(f.index))
+	copy(res, f.index)
+	return res
+}
+
+func (f *Field) Offset() uintptr {
+	return f.offset
+}
+
+func (f *Field) ColName() string {
+	return f.colName
+}
+
+func (f *Field) IsPrimaryKey() bool {
+	return f.primaryKey
+}
+
+func (f *Field) IsAutoIncrement() bool {
+	return f.autoIncrement
+}
+
+func (f *Field) NotNull() bool {
+	return f.notNull
+}
+
+func (f *Field) Unique() bool {
+	return f.unique
+}
+
+func (f *Field) Size() int {
+	return f.size
+}
+
+// IsAutoCreateTime 插入的时候没有设置值, 就会自动设置成当前时间
+func (f *Field) IsAutoCreateTime() bool {
+	return f.autoCreateTime
+}
+
+// IsAutoUpdateTime 插入和更新的时候会自动设置成当前时间
+func (f *Field) IsAutoUpdateTime() bool {
+	return f.autoUpdateTime
+}
+
+// Default 第二个返回值表示有没有设置默认值
+func (f *Field) Default() (string, bool) {
+	return f.defaultVal, f.hasDefault
 }
 
 //var models = map[reflect.Type]*Model{}
@@ -40,13 +205,24 @@ type registry struct {
 	// 读写锁
 	//lock   sync.RWMutex
 	models sync.Map
+	// naming 为 nil 的时候使用 SnakeCaseNaming
+	naming NamingStrategy
+	// strict 为 true 的时候, Get 不会自动注册
+	strict bool
 }
 
 // 全局变量
 //var defaultRegistry = &registry{models: map[reflect.Type]*Model{}}
 
 func NewRegistry() *registry {
-	return &registry{}
+	return &registry{naming: SnakeCaseNaming{}}
+}
+
+func (r *registry) namingStrategy() NamingStrategy {
+	if r.naming == nil {
+		return SnakeCaseNaming{}
+	}
+	return r.naming
 }
 
 func (r *registry) Get(val any) (*Model, error) {
@@ -55,6 +231,9 @@ func (r *registry) Get(val any) (*Model, error) {
 	if ok {
 		return m.(*Model), nil
 	}
+	if r.strict {
+		return nil, errs.NewErrModelNotRegistered(typ.String())
+	}
 	m, err := r.Register(val)
 	if err != nil {
 		return nil, err
@@ -63,6 +242,37 @@ func (r *registry) Get(val any) (*Model, error) {
 	return m.(*Model), nil
 }
 
+// RegisterAll 一般在启动的时候调用, 提前发现标签之类的错误
+// 某个模型失败了也会继续注册其它的模型
+// 已经注册过的模型会跳过, 不然之前传入的 ModelOpt 就丢了
+func (r *registry) RegisterAll(vals ...any) error {
+	var errList []error
+	for _, val := range vals {
+		if _, ok := r.models.Load(reflect.TypeOf(val)); ok {
+			continue
+		}
+		if _, err := r.Register(val); err != nil {
+			errList = append(errList, errs.NewErrRegisterModel(reflect.TypeOf(val), err))
+		}
+	}
+	if len(errList) > 0 {
+		return errs.NewErrRegisterModels(errList)
+	}
+	return nil
+}
+
+func (r *registry) Models() []*Model {
+	var res []*Model
+	r.models.Range(func(key, val any) bool {
+		res = append(res, val.(*Model))
+		return true
+	})
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].tableName < res[j].tableName
+	})
+	return res
+}
+
 //func (r *registry) Get1(val any) (*Model, error) {
 //	typ := reflect.TypeOf(val)
 //	// 读锁
@@ -98,23 +308,23 @@ func (r *registry) Register(entity any, opts ...ModelOpt) (*Model, error) {
 		return nil, errs.ErrPointerOnly
 	}
 	elemTyp := typ.Elem()
-	numField := elemTyp.NumField()
-	fieldMap := make(map[string]*Field, numField)
-	for i := 0; i < numField; i++ {
-		fd := elemTyp.Field(i)
-		pair, err := r.parseTag(fd.Tag)
-		if err != nil {
-			return nil, err
-		}
-		colName := pair[tagTestColumn]
-		if colName == "" {
-			// 用户没有设置，我们就给它转
-			colName = underscoreName(fd.Name)
-		}
-		fieldMap[fd.Name] = &Field{
-			colName: colName,
+	res := &Model{
+		fileMap:   make(map[string]*Field, elemTyp.NumField()),
+		columnMap: make(map[string]*Field, elemTyp.NumField()),
+	}
+	if err := r.parseFields(res, elemTyp, nil, 0, "", ""); err != nil {
+		return nil, err
+	}
+	for _, fd := range res.fields {
+		if fd.primaryKey {
+			res.primaryKeys = append(res.primaryKeys, fd.name)
 		}
 	}
+	// 没有用标签声明主键的话, 约定 Id 字段就是主键
+	if fd, ok := res.fileMap[conventionPrimaryKey]; ok && len(res.primaryKeys) == 0 {
+		fd.primaryKey = true
+		res.primaryKeys = []string{conventionPrimaryKey}
+	}
 
 	// 接口自定义表名
 	var tableName string
@@ -122,13 +332,10 @@ func (r *registry) Register(entity any, opts ...ModelOpt) (*Model, error) {
 		tableName = tbl.TableName()
 	}
 	if tableName == "" {
-		tableName = underscoreName(elemTyp.Name())
-	}
-
-	res := &Model{
-		tableName: tableName,
-		fileMap:   fieldMap,
+		tableName = r.namingStrategy().TableName(elemTyp.Name())
 	}
+	res.tableName = tableName
+	res.hooks = newHooks(typ)
 
 	for _, opt := range opts {
 		err := opt(res)
@@ -141,6 +348,31 @@ func (r *registry) Register(entity any, opts ...ModelOpt) (*Model, error) {
 	return res, nil
 }
 
+// TableNameResolver 在构造查询的时候决定表名, 例如按照 ctx 里面的租户或者当前月份选择表
+// entity 在 Inserter 里面是每一行数据, 在 Updater 里面是 Update 传入的实体, 其它情况下是 *T 的零值
+// 返回空字符串表示使用模型的表名
+type TableNameResolver func(ctx context.Context, entity any) (string, error)
+
+// ModelWithTableNameResolver 没有调用 From 的时候, 由 resolver 决定表名
+// 分片的模型不会使用它
+func ModelWithTableNameResolver(resolver TableNameResolver) ModelOpt {
+	return func(m *Model) error {
+		m.tableResolver = resolver
+		return nil
+	}
+}
+
+func (m *Model) resolveTableName(ctx context.Context, entity any) (string, error) {
+	if m.tableResolver == nil {
+		return m.tableName, nil
+	}
+	name, err := m.tableResolver(ctx, entity)
+	if err != nil || name == "" {
+		return m.tableName, err
+	}
+	return name, nil
+}
+
 func ModelWithTableName(tableName string) ModelOpt {
 	return func(m *Model) error {
 		m.tableName = tableName
@@ -149,17 +381,147 @@ func ModelWithTableName(tableName string) ModelOpt {
 }
 
 //field 字段名，，，，，colName 列名
+// colName 已经是别的字段的列名的时候返回错误
 func ModelWithColumnName(field string, colName string) ModelOpt {
 	return func(m *Model) error {
 		fd, ok := m.fileMap[field]
 		if !ok {
 			return errs.NewErrUnknownField(field)
 		}
+		if other, ok := m.columnMap[colName]; ok && other != fd {
+			return errs.NewErrFieldConflict(m.tableName, colName)
+		}
+		delete(m.columnMap, fd.colName)
 		fd.colName = colName
+		m.columnMap[colName] = fd
 		return nil
 	}
 }
 
+// parseFields 解析 typ 的字段并加入到 m 里面
+// 匿名的结构体, 以及打了 orm:"embedded" 标签的结构体会被展开, 展开之后字段名或者列名重复就返回错误
+// 非公开的字段会被忽略, 驱动没办法处理的类型会返回错误
+// index 和 offset 是 typ 在模型里面的路径和偏移量, namePrefix 和 colPrefix 是展开的时候累积下来的前缀
+func (r *registry) parseFields(m *Model, typ reflect.Type, index []int, offset uintptr,
+	namePrefix string, colPrefix string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		fd := typ.Field(i)
+		if fd.Tag.Get("orm") == tagIgnore {
+			continue
+		}
+		// 匿名结构体的类型名就算是小写的, 它里面的公开字段也是可以访问的
+		if !fd.IsExported() && !(fd.Anonymous && fd.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		pair, err := r.parseTag(fd.Tag)
+		if err != nil {
+			return err
+		}
+		idx := append(append(make([]int, 0, len(index)+1), index...), i)
+		_, embedded := pair[tagEmbedded]
+		if fd.Type.Kind() == reflect.Struct && (embedded || fd.Anonymous && !isValueType(fd.Type)) {
+			name := namePrefix
+			if !fd.Anonymous {
+				name = namePrefix + fd.Name + "."
+			}
+			if err = r.parseFields(m, fd.Type, idx, offset+fd.Offset, name, colPrefix+pair[tagPrefix]); err != nil {
+				return err
+			}
+			continue
+		}
+		if embedded {
+			return errs.NewErrInvalidTagContent(tagEmbedded)
+		}
+		if !isSupportedType(fd.Type) {
+			return errs.NewErrUnsupportedFieldType(typ.Name(), fd.Name, fd.Type.String())
+		}
+		field, err := r.newField(fd.Name, pair)
+		if err != nil {
+			return err
+		}
+		field.name = namePrefix + fd.Name
+		field.colName = colPrefix + field.colName
+		field.typ = fd.Type
+		field.index = idx
+		field.offset = offset + fd.Offset
+		if err = field.parseAutoTime(fd.Name, pair); err != nil {
+			return err
+		}
+		if _, ok := m.fileMap[field.name]; ok {
+			return errs.NewErrFieldConflict(typ.Name(), field.name)
+		}
+		if _, ok := m.columnMap[field.colName]; ok {
+			return errs.NewErrFieldConflict(typ.Name(), field.colName)
+		}
+		m.fileMap[field.name] = field
+		m.columnMap[field.colName] = field
+		m.fields = append(m.fields, field)
+	}
+	return nil
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isValueType 自己就能和数据库的值互相转换的类型, 例如 time.Time 和 sql.NullString
+func isValueType(typ reflect.Type) bool {
+	return typ == timeType || typ.Implements(valuerType) ||
+		reflect.PointerTo(typ).Implements(scannerType)
+}
+
+// isSupportedType 驱动能够处理的类型, 指针看它指向的类型
+func isSupportedType(typ reflect.Type) bool {
+	if isValueType(typ) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		// 只支持 []byte
+		return typ.Elem().Kind() == reflect.Uint8
+	case reflect.Pointer:
+		return isSupportedType(typ.Elem())
+	default:
+		return false
+	}
+}
+
+// newField 根据解析出来的标签创建字段
+func (r *registry) newField(name string, pair map[string]string) (*Field, error) {
+	colName := pair[tagTestColumn]
+	if colName == "" {
+		// 用户没有设置，我们就给它转
+		colName = r.namingStrategy().ColumnName(name)
+	}
+	res := &Field{
+		name:    name,
+		colName: colName,
+	}
+	_, res.primaryKey = pair[tagPrimaryKey]
+	_, res.autoIncrement = pair[tagAutoIncrement]
+	_, res.notNull = pair[tagNotNull]
+	_, res.unique = pair[tagUnique]
+	res.defaultVal, res.hasDefault = pair[tagDefault]
+	if size, ok := pair[tagSize]; ok && size != "" {
+		val, err := strconv.Atoi(size)
+		if err != nil || val < 0 {
+			return nil, errs.NewErrInvalidTagContent(tagSize + "=" + size)
+		}
+		res.size = val
+	}
+	return res, nil
+}
+
+// parseTag 支持 key=value 和只有 key 的两种形式
+// orm:"column=id,pk,autoincr" 解析出来是 {"column": "id", "pk": "", "autoincr": ""}
+// 不认识的标签会返回错误
 func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
 	ormTag, ok := tag.Lookup("orm")
 	if !ok {
@@ -168,30 +530,21 @@ func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
 	pairs := strings.Split(ormTag, ",")
 	res := make(map[string]string, len(pairs))
 	for _, pair := range pairs {
-		segs := strings.Split(pair, "=")
-		if len(segs) != 2 {
+		// default 的值里面可能有 =
+		segs := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(segs[0])
+		_, withValue := tagsWithValue[key]
+		if _, ok := tagsWithoutValue[key]; !ok && !withValue {
 			return nil, errs.NewErrInvalidTagContent(pair)
 		}
-		key := segs[0]
-		val := segs[1]
-		res[key] = val
-	}
-	return res, nil
-}
-
-// underscoreName 驼峰转字符串命名
-func underscoreName(tableName string) string {
-	var buf []byte
-	for i, v := range tableName {
-		if unicode.IsUpper(v) {
-			if i != 0 {
-				buf = append(buf, '_')
+		if len(segs) == 1 {
+			if withValue {
+				return nil, errs.NewErrInvalidTagContent(pair)
 			}
-			buf = append(buf, byte(unicode.ToLower(v)))
-		} else {
-			buf = append(buf, byte(v))
+			res[key] = ""
+			continue
 		}
-
+		res[key] = segs[1]
 	}
-	return string(buf)
+	return res, nil
 }
@@ -2,9 +2,12 @@ package Go_ORM
 
 import (
 	"Go_ORM/internal/errs"
+	"database/sql"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_Register(t *testing.T) {
@@ -26,23 +29,12 @@ func Test_Register(t *testing.T) {
 		{
 			name:   "pointer",
 			entity: &TestModel{},
-			wantModel: &Model{
-				tableName: "test_model",
-				fileMap: map[string]*Field{
-					"Id": {
-						colName: "id",
-					},
-					"FirstName": {
-						colName: "first_name",
-					},
-					"LastName": {
-						colName: "last_name",
-					},
-					"Age": {
-						colName: "age",
-					},
-				},
-			},
+			wantModel: newTestModel("test_model", []string{"Id"},
+				&Field{name: "Id", colName: "id", index: []int{0}, typ: reflect.TypeOf(int64(0)), primaryKey: true},
+				&Field{name: "Age", colName: "age", index: []int{1}, typ: reflect.TypeOf(int8(0)), offset: 8},
+				&Field{name: "FirstName", colName: "first_name", index: []int{2}, typ: reflect.TypeOf(""), offset: 16},
+				&Field{name: "LastName", colName: "last_name", index: []int{3}, typ: reflect.TypeOf(&sql.NullString{}), offset: 32},
+			),
 		},
 
 		{
@@ -89,23 +81,12 @@ func TestRegistry_Get(t *testing.T) {
 		{
 			name:   "pointer",
 			entity: &TestModel{},
-			wantModel: &Model{
-				tableName: "test_model",
-				fileMap: map[string]*Field{
-					"Id": {
-						colName: "id",
-					},
-					"FirstName": {
-						colName: "first_name",
-					},
-					"LastName": {
-						colName: "last_name",
-					},
-					"Age": {
-						colName: "age",
-					},
-				},
-			},
+			wantModel: newTestModel("test_model", []string{"Id"},
+				&Field{name: "Id", colName: "id", index: []int{0}, typ: reflect.TypeOf(int64(0)), primaryKey: true},
+				&Field{name: "Age", colName: "age", index: []int{1}, typ: reflect.TypeOf(int8(0)), offset: 8},
+				&Field{name: "FirstName", colName: "first_name", index: []int{2}, typ: reflect.TypeOf(""), offset: 16},
+				&Field{name: "LastName", colName: "last_name", index: []int{3}, typ: reflect.TypeOf(&sql.NullString{}), offset: 32},
+			),
 		},
 
 		{
@@ -117,14 +98,9 @@ func TestRegistry_Get(t *testing.T) {
 				}
 				return &TagTable{}
 			}(),
-			wantModel: &Model{
-				tableName: "tag_table",
-				fileMap: map[string]*Field{
-					"FirstName": {
-						colName: "first_name_t",
-					},
-				},
-			},
+			wantModel: newTestModel("tag_table", nil,
+				&Field{name: "FirstName", colName: "first_name_t", index: []int{0}, typ: reflect.TypeOf("")},
+			),
 		},
 
 		{
@@ -136,14 +112,9 @@ func TestRegistry_Get(t *testing.T) {
 				}
 				return &TagTable{}
 			}(),
-			wantModel: &Model{
-				tableName: "tag_table",
-				fileMap: map[string]*Field{
-					"FirstName": {
-						colName: "first_name",
-					},
-				},
-			},
+			wantModel: newTestModel("tag_table", nil,
+				&Field{name: "FirstName", colName: "first_name", index: []int{0}, typ: reflect.TypeOf("")},
+			),
 		},
 
 		{
@@ -159,7 +130,7 @@ func TestRegistry_Get(t *testing.T) {
 		},
 
 		{
-			name: "ignore tag",
+			name: "unknown tag",
 			// 局部匿名方法
 			entity: func() any {
 				type TagTable struct {
@@ -167,53 +138,105 @@ func TestRegistry_Get(t *testing.T) {
 				}
 				return &TagTable{}
 			}(),
-			wantModel: &Model{
-				tableName: "tag_table",
-				fileMap: map[string]*Field{
-					"FirstName": {
-						colName: "first_name",
-					},
-				},
-			},
+			wantErr: errs.NewErrInvalidTagContent("abc=abc"),
+		},
+
+		{
+			name: "unknown flag tag",
+			entity: func() any {
+				type TagTable struct {
+					Id int64 `orm:"primary_key,autoincrement"`
+				}
+				return &TagTable{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("primary_key"),
+		},
+
+		{
+			name: "misspelled column",
+			entity: func() any {
+				type TagTable struct {
+					FirstName string `orm:"colum=nm"`
+				}
+				return &TagTable{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("colum=nm"),
+		},
+
+		{
+			name: "flag tags",
+			entity: func() any {
+				type TagTable struct {
+					Id        int64  `orm:"column=id,pk,autoincr"`
+					FirstName string `orm:"size=255,default=0,notnull,unique"`
+					Expr      string `orm:"default=a=b"`
+					Ignored   string `orm:"-"`
+				}
+				return &TagTable{}
+			}(),
+			wantModel: newTestModel("tag_table", []string{"Id"},
+				&Field{name: "Id", colName: "id", index: []int{0}, typ: reflect.TypeOf(int64(0)), primaryKey: true, autoIncrement: true},
+				&Field{name: "FirstName", colName: "first_name", index: []int{1}, typ: reflect.TypeOf(""), offset: 8, size: 255,
+					defaultVal: "0", hasDefault: true, notNull: true, unique: true},
+				&Field{name: "Expr", colName: "expr", index: []int{2}, typ: reflect.TypeOf(""), offset: 24, defaultVal: "a=b", hasDefault: true},
+			),
+		},
+
+		{
+			name: "size only",
+			entity: func() any {
+				type TagTable struct {
+					FirstName string `orm:"size"`
+				}
+				return &TagTable{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("size"),
+		},
+
+		{
+			name: "invalid size",
+			entity: func() any {
+				type TagTable struct {
+					FirstName string `orm:"size=abc"`
+				}
+				return &TagTable{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("size=abc"),
+		},
+
+		{
+			name: "empty tag item",
+			entity: func() any {
+				type TagTable struct {
+					FirstName string `orm:"pk,,unique"`
+				}
+				return &TagTable{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent(""),
 		},
 
 		{
 			name:   "table name",
 			entity: &CustomTableName{},
-			wantModel: &Model{
-				tableName: "custom_table_name_t",
-				fileMap: map[string]*Field{
-					"FirstName": {
-						colName: "first_name",
-					},
-				},
-			},
+			wantModel: newTestModel("custom_table_name_t", nil,
+				&Field{name: "FirstName", colName: "first_name", index: []int{0}, typ: reflect.TypeOf("")},
+			),
 		},
 
 		{
 			name:   "table name ptr",
 			entity: &CustomTableNamePtr{},
-			wantModel: &Model{
-				tableName: "custom_table_name_ptr_t",
-				fileMap: map[string]*Field{
-					"FirstName": {
-						colName: "first_name",
-					},
-				},
-			},
+			wantModel: newTestModel("custom_table_name_ptr_t", nil,
+				&Field{name: "FirstName", colName: "first_name", index: []int{0}, typ: reflect.TypeOf("")},
+			),
 		},
 
 		{
 			name:   "empty table name",
 			entity: &EmptyTableName{},
-			wantModel: &Model{
-				tableName: "empty_table_name",
-				fileMap: map[string]*Field{
-					"FirstName": {
-						colName: "first_name",
-					},
-				},
-			},
+			wantModel: newTestModel("empty_table_name", nil,
+				&Field{name: "FirstName", colName: "first_name", index: []int{0}, typ: reflect.TypeOf("")},
+			),
 		},
 	}
 
@@ -236,6 +259,22 @@ func TestRegistry_Get(t *testing.T) {
 	}
 }
 
+// newTestModel 按照字段定义的顺序构造期望的元数据
+func newTestModel(tableName string, pks []string, fields ...*Field) *Model {
+	m := &Model{
+		tableName:   tableName,
+		fileMap:     make(map[string]*Field, len(fields)),
+		columnMap:   make(map[string]*Field, len(fields)),
+		fields:      fields,
+		primaryKeys: pks,
+	}
+	for _, fd := range fields {
+		m.fileMap[fd.name] = fd
+		m.columnMap[fd.colName] = fd
+	}
+	return m
+}
+
 // Go里面 结构体实现接口 与 结构体指针实现接口 两者是不等价的
 type CustomTableName struct {
 	FirstName string
@@ -300,6 +339,22 @@ func TestModelWithColumnName(t *testing.T) {
 			wantErr: errs.NewErrUnknownField("xxx"),
 		},
 
+		{
+			name:    "column conflict",
+			field:   "Age",
+			colName: "first_name",
+
+			wantErr: errs.NewErrFieldConflict("test_model", "first_name"),
+		},
+
+		{
+			name:    "same column name",
+			field:   "Age",
+			colName: "age",
+
+			wantColName: "age",
+		},
+
 		{
 			name:    "empty column name",
 			field:   "FirstName",
@@ -323,3 +378,400 @@ func TestModelWithColumnName(t *testing.T) {
 		})
 	}
 }
+
+type BaseModel struct {
+	Id        int64
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type Address struct {
+	City   string
+	Street string
+}
+
+func TestRegistry_Embedded(t *testing.T) {
+	testCases := []struct {
+		name   string
+		entity any
+
+		wantModel *Model
+		wantErr   error
+	}{
+		{
+			name: "anonymous",
+			entity: func() any {
+				type User struct {
+					BaseModel
+					Name string
+				}
+				return &User{}
+			}(),
+			wantModel: newTestModel("user", []string{"Id"},
+				&Field{name: "Id", colName: "id", index: []int{0, 0}, typ: reflect.TypeOf(int64(0)), primaryKey: true},
+				&Field{name: "CreatedAt", colName: "created_at", index: []int{0, 1}, typ: reflect.TypeOf(int64(0)), offset: 8,
+					autoCreateTime: true},
+				&Field{name: "UpdatedAt", colName: "updated_at", index: []int{0, 2}, typ: reflect.TypeOf(int64(0)), offset: 16,
+					autoUpdateTime: true},
+				&Field{name: "Name", colName: "name", index: []int{1}, typ: reflect.TypeOf(""), offset: 24},
+			),
+		},
+
+		{
+			name: "embedded with prefix",
+			entity: func() any {
+				type User struct {
+					Id   int64
+					Addr Address `orm:"embedded,prefix=addr_"`
+				}
+				return &User{}
+			}(),
+			wantModel: newTestModel("user", []string{"Id"},
+				&Field{name: "Id", colName: "id", index: []int{0}, typ: reflect.TypeOf(int64(0)), primaryKey: true},
+				&Field{name: "Addr.City", colName: "addr_city", index: []int{1, 0}, typ: reflect.TypeOf(""), offset: 8},
+				&Field{name: "Addr.Street", colName: "addr_street", index: []int{1, 1}, typ: reflect.TypeOf(""), offset: 24},
+			),
+		},
+
+		{
+			name: "embedded without prefix",
+			entity: func() any {
+				type User struct {
+					Addr Address `orm:"embedded"`
+				}
+				return &User{}
+			}(),
+			wantModel: newTestModel("user", nil,
+				&Field{name: "Addr.City", colName: "city", index: []int{0, 0}, typ: reflect.TypeOf("")},
+				&Field{name: "Addr.Street", colName: "street", index: []int{0, 1}, typ: reflect.TypeOf(""), offset: 16},
+			),
+		},
+
+		{
+			name: "field conflict",
+			entity: func() any {
+				type User struct {
+					Id int64
+					BaseModel
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrFieldConflict("BaseModel", "Id"),
+		},
+
+		{
+			name: "column conflict",
+			entity: func() any {
+				type User struct {
+					City string
+					Addr Address `orm:"embedded"`
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrFieldConflict("Address", "city"),
+		},
+
+		{
+			name: "embedded non struct",
+			entity: func() any {
+				type User struct {
+					Name string `orm:"embedded"`
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("embedded"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewRegistry().Register(tc.entity)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantModel, m)
+		})
+	}
+}
+
+type baseModel struct {
+	Id int64
+}
+
+func TestRegistry_FieldTypes(t *testing.T) {
+	testCases := []struct {
+		name   string
+		entity any
+
+		wantModel *Model
+		wantErr   error
+	}{
+		{
+			name: "unexported",
+			entity: func() any {
+				type User struct {
+					baseModel
+					Name     string
+					password string
+				}
+				return &User{}
+			}(),
+			wantModel: newTestModel("user", []string{"Id"},
+				&Field{name: "Id", colName: "id", index: []int{0, 0}, typ: reflect.TypeOf(int64(0)), primaryKey: true},
+				&Field{name: "Name", colName: "name", index: []int{1}, typ: reflect.TypeOf(""), offset: 8},
+			),
+		},
+
+		{
+			name: "value types",
+			entity: func() any {
+				type User struct {
+					sql.NullString
+					CreatedAt time.Time
+					DeletedAt *time.Time
+					Avatar    []byte
+					Nickname  *string
+				}
+				return &User{}
+			}(),
+			wantModel: newTestModel("user", nil,
+				&Field{name: "NullString", colName: "null_string", index: []int{0}, typ: reflect.TypeOf(sql.NullString{})},
+				&Field{name: "CreatedAt", colName: "created_at", index: []int{1}, typ: reflect.TypeOf(time.Time{}), offset: 24,
+					autoCreateTime: true},
+				&Field{name: "DeletedAt", colName: "deleted_at", index: []int{2}, typ: reflect.TypeOf(&time.Time{}), offset: 48},
+				&Field{name: "Avatar", colName: "avatar", index: []int{3}, typ: reflect.TypeOf([]byte{}), offset: 56},
+				&Field{name: "Nickname", colName: "nickname", index: []int{4}, typ: reflect.TypeOf(new(string)), offset: 80},
+			),
+		},
+
+		{
+			name: "map",
+			entity: func() any {
+				type User struct {
+					Extra map[string]string
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrUnsupportedFieldType("User", "Extra", "map[string]string"),
+		},
+
+		{
+			name: "func",
+			entity: func() any {
+				type User struct {
+					Fn func()
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrUnsupportedFieldType("User", "Fn", "func()"),
+		},
+
+		{
+			name: "chan",
+			entity: func() any {
+				type User struct {
+					Ch chan int
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrUnsupportedFieldType("User", "Ch", "chan int"),
+		},
+
+		{
+			name: "struct",
+			entity: func() any {
+				type User struct {
+					Addr Address
+				}
+				return &User{}
+			}(),
+			wantErr: errs.NewErrUnsupportedFieldType("User", "Addr", "Go_ORM.Address"),
+		},
+
+		{
+			name: "ignored map",
+			entity: func() any {
+				type User struct {
+					Name  string
+					Extra map[string]string `orm:"-"`
+				}
+				return &User{}
+			}(),
+			wantModel: newTestModel("user", nil,
+				&Field{name: "Name", colName: "name", index: []int{0}, typ: reflect.TypeOf("")},
+			),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewRegistry().Register(tc.entity)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantModel, m)
+		})
+	}
+}
+
+func TestField_Getters(t *testing.T) {
+	type TagTable struct {
+		Id   int64  `orm:"pk,autoincr"`
+		Name string `orm:"column=name_t,size=64,default=Tom,notnull,unique"`
+	}
+	m, err := NewRegistry().Register(&TagTable{})
+	assert.NoError(t, err)
+
+	id := m.fileMap["Id"]
+	assert.True(t, id.IsPrimaryKey())
+	assert.True(t, id.IsAutoIncrement())
+	_, ok := id.Default()
+	assert.False(t, ok)
+
+	name := m.fileMap["Name"]
+	assert.Equal(t, "name_t", name.ColName())
+	assert.Equal(t, 64, name.Size())
+	assert.True(t, name.NotNull())
+	assert.True(t, name.Unique())
+	val, ok := name.Default()
+	assert.True(t, ok)
+	assert.Equal(t, "Tom", val)
+}
+
+func TestRegistry_PrimaryKeys(t *testing.T) {
+	testCases := []struct {
+		name   string
+		entity any
+
+		wantPks []string
+	}{
+		{
+			name:    "convention",
+			entity:  &TestModel{},
+			wantPks: []string{"Id"},
+		},
+
+		{
+			// 用标签声明了主键, Id 就不是主键了
+			name: "tag",
+			entity: func() any {
+				type TagTable struct {
+					Id     int64
+					UserId int64 `orm:"pk"`
+				}
+				return &TagTable{}
+			}(),
+			wantPks: []string{"UserId"},
+		},
+
+		{
+			name: "composite",
+			entity: func() any {
+				type TagTable struct {
+					UserId  int64 `orm:"pk"`
+					Name    string
+					OrderId int64 `orm:"pk"`
+				}
+				return &TagTable{}
+			}(),
+			wantPks: []string{"UserId", "OrderId"},
+		},
+
+		{
+			name: "no primary key",
+			entity: func() any {
+				type TagTable struct {
+					Name string
+				}
+				return &TagTable{}
+			}(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewRegistry().Register(tc.entity)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantPks, m.PrimaryKeys())
+			for _, pk := range tc.wantPks {
+				assert.True(t, m.fileMap[pk].IsPrimaryKey())
+			}
+		})
+	}
+}
+
+func TestModel_Introspection(t *testing.T) {
+	m, err := NewRegistry().Register(&TestModel{}, ModelWithColumnName("FirstName", "first_name_t"))
+	assert.NoError(t, err)
+
+	fields := m.Fields()
+	names := make([]string, 0, len(fields))
+	for _, fd := range fields {
+		names = append(names, fd.Name())
+	}
+	assert.Equal(t, []string{"Id", "Age", "FirstName", "LastName"}, names)
+	// 修改返回的切片不影响元数据
+	fields[0] = nil
+	assert.NotNil(t, m.Fields()[0])
+
+	fd, ok := m.FieldByColumn("first_name_t")
+	assert.True(t, ok)
+	assert.Equal(t, "FirstName", fd.Name())
+	assert.Equal(t, reflect.TypeOf(""), fd.Type())
+	assert.Equal(t, []int{2}, fd.Index())
+	assert.Equal(t, uintptr(16), fd.Offset())
+	_, ok = m.FieldByColumn("first_name")
+	assert.False(t, ok)
+
+	_, ok = m.FieldByColumn("xxx")
+	assert.False(t, ok)
+}
+
+func TestRegistry_RegisterAll(t *testing.T) {
+	type InvalidTag struct {
+		FirstName string `orm:"column"`
+	}
+	type InvalidType struct {
+		Extra map[string]string
+	}
+
+	r := NewRegistry()
+	err := r.RegisterAll(&TestModel{}, &InvalidTag{}, &CustomTableName{}, &InvalidType{})
+	assert.Equal(t, "orm: 注册模型失败\n"+
+		"*Go_ORM.InvalidTag: orm: 非法标签值 column\n"+
+		"*Go_ORM.InvalidType: orm: InvalidType.Extra 的类型 map[string]string 不能映射到列", err.Error())
+
+	// 失败的模型不影响其它模型
+	tables := make([]string, 0, 2)
+	for _, m := range r.Models() {
+		tables = append(tables, m.TableName())
+	}
+	assert.Equal(t, []string{"custom_table_name_t", "test_model"}, tables)
+
+	assert.NoError(t, r.RegisterAll(&TestModel{}, &EmptyTableName{}))
+	assert.Len(t, r.Models(), 3)
+
+	// 已经注册过的模型不会被覆盖
+	m, err := r.Register(&TestModel{}, ModelWithTableName("test_model_t"))
+	require.NoError(t, err)
+	assert.NoError(t, r.RegisterAll(&TestModel{}))
+	res, err := r.Get(&TestModel{})
+	require.NoError(t, err)
+	assert.Same(t, m, res)
+	assert.Equal(t, "test_model_t", res.TableName())
+}
+
+func TestDBWithStrictRegistry(t *testing.T) {
+	db := MustNewDB(DBWithStrictRegistry())
+	_, err := NewSelector[TestModel](db).Build()
+	assert.Equal(t, errs.NewErrModelNotRegistered("*Go_ORM.TestModel"), err)
+	assert.Empty(t, db.Models())
+
+	assert.NoError(t, db.RegisterAll(&TestModel{}))
+	q, err := NewSelector[TestModel](db).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Query{SQL: "SELECT * FROM `test_model`;"}, q)
+	assert.Len(t, db.Models(), 1)
+}
@@ -0,0 +1,137 @@
+package Go_ORM
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy 命名策略, 没有通过标签或者 TableName 接口指定名字的时候,
+// 用它把结构体名转成表名, 把字段名转成列名
+type NamingStrategy interface {
+	TableName(structName string) string
+	ColumnName(fieldName string) string
+}
+
+// DBWithNamingStrategy 指定命名策略, 默认是 SnakeCaseNaming
+func DBWithNamingStrategy(naming NamingStrategy) DBOption {
+	return func(db *DB) {
+		db.r.naming = naming
+	}
+}
+
+// SnakeCaseNaming 转成下划线命名, 连续的大写字母被当成一个单词
+// UserID => user_id, HTTPServer => http_server
+type SnakeCaseNaming struct{}
+
+func (SnakeCaseNaming) TableName(structName string) string {
+	return snakeCase(structName)
+}
+
+func (SnakeCaseNaming) ColumnName(fieldName string) string {
+	return snakeCase(fieldName)
+}
+
+// CamelCaseNaming 转成小驼峰命名
+// UserID => userID, HTTPServer => httpServer
+type CamelCaseNaming struct{}
+
+func (CamelCaseNaming) TableName(structName string) string {
+	return camelCase(structName)
+}
+
+func (CamelCaseNaming) ColumnName(fieldName string) string {
+	return camelCase(fieldName)
+}
+
+// TablePrefixNaming 在 NamingStrategy 生成的表名前面加上 Prefix, 列名不受影响
+// NamingStrategy 没有设置的时候使用 SnakeCaseNaming
+type TablePrefixNaming struct {
+	NamingStrategy
+	Prefix string
+}
+
+func (t TablePrefixNaming) TableName(structName string) string {
+	return t.Prefix + orSnakeCase(t.NamingStrategy).TableName(structName)
+}
+
+func (t TablePrefixNaming) ColumnName(fieldName string) string {
+	return orSnakeCase(t.NamingStrategy).ColumnName(fieldName)
+}
+
+// PluralTableNaming 把 NamingStrategy 生成的表名转成复数形式, 列名不受影响
+// 只处理英语里面规则的变化, user => users, category => categories, address => addresses
+// NamingStrategy 没有设置的时候使用 SnakeCaseNaming
+type PluralTableNaming struct {
+	NamingStrategy
+}
+
+func (p PluralTableNaming) TableName(structName string) string {
+	return plural(orSnakeCase(p.NamingStrategy).TableName(structName))
+}
+
+func (p PluralTableNaming) ColumnName(fieldName string) string {
+	return orSnakeCase(p.NamingStrategy).ColumnName(fieldName)
+}
+
+// orSnakeCase 和 registry.namingStrategy 一样, 默认是 SnakeCaseNaming
+func orSnakeCase(naming NamingStrategy) NamingStrategy {
+	if naming == nil {
+		return SnakeCaseNaming{}
+	}
+	return naming
+}
+
+// snakeCase 在单词的边界加上下划线
+// 小写字母或者数字后面的大写字母是新单词的开始,
+// 连续的大写字母里面, 后面跟着小写字母的那个大写字母也是新单词的开始
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	sb.Grow(len(name) + 4)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			sb.WriteRune(r)
+			continue
+		}
+		if i > 0 {
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) && prev != '_' ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(prev) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// camelCase 把开头的单词转成小写
+func camelCase(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	// HTTPServer 的 S 属于下一个单词
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+func plural(name string) string {
+	switch {
+	case name == "":
+		return name
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
@@ -0,0 +1,176 @@
+package Go_ORM
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNamingStrategy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		naming NamingStrategy
+		input  string
+
+		wantTable  string
+		wantColumn string
+	}{
+		{
+			name:       "snake case",
+			naming:     SnakeCaseNaming{},
+			input:      "FirstName",
+			wantTable:  "first_name",
+			wantColumn: "first_name",
+		},
+		{
+			name:       "snake case acronym at end",
+			naming:     SnakeCaseNaming{},
+			input:      "UserID",
+			wantTable:  "user_id",
+			wantColumn: "user_id",
+		},
+		{
+			name:       "snake case acronym at start",
+			naming:     SnakeCaseNaming{},
+			input:      "HTTPServer",
+			wantTable:  "http_server",
+			wantColumn: "http_server",
+		},
+		{
+			name:       "snake case digit",
+			naming:     SnakeCaseNaming{},
+			input:      "OrderV2Item",
+			wantTable:  "order_v2_item",
+			wantColumn: "order_v2_item",
+		},
+		{
+			name:       "snake case non ascii",
+			naming:     SnakeCaseNaming{},
+			input:      "ÜserNämé",
+			wantTable:  "üser_nämé",
+			wantColumn: "üser_nämé",
+		},
+		{
+			name:       "snake case underscore",
+			naming:     SnakeCaseNaming{},
+			input:      "User_Name",
+			wantTable:  "user_name",
+			wantColumn: "user_name",
+		},
+		{
+			name:       "camel case",
+			naming:     CamelCaseNaming{},
+			input:      "FirstName",
+			wantTable:  "firstName",
+			wantColumn: "firstName",
+		},
+		{
+			name:       "camel case acronym",
+			naming:     CamelCaseNaming{},
+			input:      "HTTPServer",
+			wantTable:  "httpServer",
+			wantColumn: "httpServer",
+		},
+		{
+			name:       "camel case all upper",
+			naming:     CamelCaseNaming{},
+			input:      "ID",
+			wantTable:  "id",
+			wantColumn: "id",
+		},
+		{
+			name:       "table prefix",
+			naming:     TablePrefixNaming{NamingStrategy: SnakeCaseNaming{}, Prefix: "t_"},
+			input:      "UserID",
+			wantTable:  "t_user_id",
+			wantColumn: "user_id",
+		},
+		{
+			name:       "plural",
+			naming:     PluralTableNaming{NamingStrategy: SnakeCaseNaming{}},
+			input:      "OrderItem",
+			wantTable:  "order_items",
+			wantColumn: "order_item",
+		},
+		{
+			name:       "plural y",
+			naming:     PluralTableNaming{NamingStrategy: SnakeCaseNaming{}},
+			input:      "Category",
+			wantTable:  "categories",
+			wantColumn: "category",
+		},
+		{
+			name:       "plural vowel y",
+			naming:     PluralTableNaming{NamingStrategy: SnakeCaseNaming{}},
+			input:      "Day",
+			wantTable:  "days",
+			wantColumn: "day",
+		},
+		{
+			name:       "plural es",
+			naming:     PluralTableNaming{NamingStrategy: SnakeCaseNaming{}},
+			input:      "Address",
+			wantTable:  "addresses",
+			wantColumn: "address",
+		},
+		{
+			name:       "prefix zero value",
+			naming:     TablePrefixNaming{Prefix: "t_"},
+			input:      "OrderItem",
+			wantTable:  "t_order_item",
+			wantColumn: "order_item",
+		},
+		{
+			name:       "plural zero value",
+			naming:     PluralTableNaming{},
+			input:      "OrderItem",
+			wantTable:  "order_items",
+			wantColumn: "order_item",
+		},
+		{
+			name: "prefix and plural",
+			naming: TablePrefixNaming{
+				NamingStrategy: PluralTableNaming{NamingStrategy: SnakeCaseNaming{}},
+				Prefix:         "t_",
+			},
+			input:      "Box",
+			wantTable:  "t_boxes",
+			wantColumn: "box",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantTable, tc.naming.TableName(tc.input))
+			assert.Equal(t, tc.wantColumn, tc.naming.ColumnName(tc.input))
+		})
+	}
+}
+
+func TestDBWithNamingStrategy(t *testing.T) {
+	type OrderItem struct {
+		ItemID    int64
+		FirstName string `orm:"column=first_name"`
+	}
+	db, err := NewDB(DBWithNamingStrategy(TablePrefixNaming{
+		NamingStrategy: PluralTableNaming{NamingStrategy: CamelCaseNaming{}},
+		Prefix:         "t_",
+	}))
+	require.NoError(t, err)
+	q, err := NewSelector[OrderItem](db).Where(C("ItemID").Eq(1)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "SELECT * FROM `t_orderItems` WHERE `itemID` = ?;",
+		Args: []any{1},
+	}, q)
+
+	// 没有设置内层的 NamingStrategy
+	db, err = NewDB(DBWithNamingStrategy(TablePrefixNaming{Prefix: "t_"}))
+	require.NoError(t, err)
+	q, err = NewSelector[OrderItem](db).Where(C("ItemID").Eq(1)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "SELECT * FROM `t_order_item` WHERE `item_id` = ?;",
+		Args: []any{1},
+	}, q)
+}
@@ -0,0 +1,102 @@
+package Go_ORM
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Replica 从库, Weight 越大分到的查询越多
+type Replica struct {
+	DB     *sql.DB
+	Weight int
+}
+
+// DBWithReplicas 设置从库, Get 和 GetMulti 会轮询这些从库
+// Exec 和事务里面的查询始终走主库
+func DBWithReplicas(replicas ...*sql.DB) DBOption {
+	weighted := make([]Replica, 0, len(replicas))
+	for _, r := range replicas {
+		weighted = append(weighted, Replica{DB: r, Weight: 1})
+	}
+	return DBWithWeightedReplicas(weighted...)
+}
+
+// DBWithWeightedReplicas 按照权重把查询分给从库
+func DBWithWeightedReplicas(replicas ...Replica) DBOption {
+	return func(db *DB) {
+		db.replicas = newReplicaGroup(replicas)
+	}
+}
+
+type useMasterKey struct{}
+
+// UseMaster 用这个 ctx 执行的查询会走主库, 用于写完立刻读的场景
+func UseMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, useMasterKey{}, true)
+}
+
+// IsUseMaster 缓存之类的 Middleware 要用它判断, 不能把从库或者之前的结果交给要求读主库的查询
+func IsUseMaster(ctx context.Context) bool {
+	val, _ := ctx.Value(useMasterKey{}).(bool)
+	return val
+}
+
+// replicaGroup 平滑加权轮询, 权重都一样的时候就是普通的轮询
+type replicaGroup struct {
+	mutex    sync.Mutex
+	replicas []*weightedReplica
+	total    int
+}
+
+type weightedReplica struct {
+	db      *sql.DB
+	weight  int
+	current int
+}
+
+func newReplicaGroup(replicas []Replica) *replicaGroup {
+	res := &replicaGroup{
+		replicas: make([]*weightedReplica, 0, len(replicas)),
+	}
+	for _, r := range replicas {
+		// 权重不大于 0 的从库不参与
+		if r.Weight <= 0 {
+			continue
+		}
+		res.replicas = append(res.replicas, &weightedReplica{db: r.DB, weight: r.Weight})
+		res.total += r.Weight
+	}
+	return res
+}
+
+// next 没有从库的时候返回 nil
+func (g *replicaGroup) next() *sql.DB {
+	if g == nil || len(g.replicas) == 0 {
+		return nil
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	var best *weightedReplica
+	for _, r := range g.replicas {
+		r.current += r.weight
+		if best == nil || r.current > best.current {
+			best = r
+		}
+	}
+	best.current -= g.total
+	return best.db
+}
+
+func (g *replicaGroup) close() error {
+	if g == nil {
+		return nil
+	}
+	var err error
+	for _, r := range g.replicas {
+		if e := r.db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package Go_ORM
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+// newSQLiteReplica 用一个单独的文件模拟从库, 里面只有一行 id 为 id 的数据
+func newSQLiteReplica(t *testing.T, id int64) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "replica.db"))
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE test_model(id INTEGER PRIMARY KEY, first_name TEXT NOT NULL, age INTEGER, last_name TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO test_model(id, first_name, age) VALUES (?, 'replica', 0)", id)
+	require.NoError(t, err)
+	return db
+}
+
+// readIds 每次查询落到哪个库上
+func readIds(t *testing.T, ctx context.Context, db *DB, n int) []int64 {
+	res := make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		tm, err := NewSelector[TestModel](db).Get(ctx)
+		require.NoError(t, err)
+		res = append(res, tm.Id)
+	}
+	return res
+}
+
+func TestDBWithReplicas(t *testing.T) {
+	db := newSQLiteDB(t, DBWithReplicas(newSQLiteReplica(t, 2), newSQLiteReplica(t, 3)))
+	ctx := context.Background()
+
+	// 写走主库
+	_, err := NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "primary"}).Exec(ctx)
+	require.NoError(t, err)
+
+	// 读轮询从库
+	assert.Equal(t, []int64{2, 3, 2, 3}, readIds(t, ctx, db, 4))
+
+	// 强制走主库
+	assert.Equal(t, []int64{1, 1}, readIds(t, UseMaster(ctx), db, 2))
+
+	// 事务里面走主库
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		tm, err := NewSelector[TestModel](tx).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), tm.Id)
+		// 用 DB 创建的查询也会加入 ctx 里面的事务
+		assert.Equal(t, []int64{1}, readIds(t, ctx, db, 1))
+		return nil
+	}, nil)
+	require.NoError(t, err)
+}
+
+func TestDBWithWeightedReplicas(t *testing.T) {
+	db := newSQLiteDB(t, DBWithWeightedReplicas(
+		Replica{DB: newSQLiteReplica(t, 2), Weight: 2},
+		Replica{DB: newSQLiteReplica(t, 3), Weight: 1},
+		Replica{DB: newSQLiteReplica(t, 4), Weight: 0},
+	))
+	assert.Equal(t, []int64{2, 3, 2, 2, 3, 2}, readIds(t, context.Background(), db, 6))
+}
+
+func TestDB_NoReplicas(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	_, err := NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "primary"}).Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 1}, readIds(t, ctx, db, 2))
+}
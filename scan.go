@@ -0,0 +1,33 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"database/sql"
+	"reflect"
+	"unsafe"
+)
+
+// scanRow 把 rows 当前行的数据映射到一个新的 T 上
+// 调用者要先调用 rows.Next()
+func scanRow[T any](m *Model, rows *sql.Rows) (*T, error) {
+	cs, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	tp := new(T)
+	// 列的顺序由 SQL 决定, 所以要按照列名找到对应的字段
+	// 字段的地址 = 结构体的起始地址 + 偏移量
+	base := unsafe.Pointer(tp)
+	vals := make([]any, 0, len(cs))
+	for _, c := range cs {
+		fd, ok := m.columnMap[c]
+		if !ok {
+			return nil, errs.NewErrUnknownColumn(c)
+		}
+		vals = append(vals, reflect.NewAt(fd.typ, unsafe.Add(base, fd.offset)).Interface())
+	}
+	if err = rows.Scan(vals...); err != nil {
+		return nil, err
+	}
+	return tp, nil
+}
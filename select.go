@@ -3,141 +3,286 @@ package Go_ORM
 import (
 	"Go_ORM/internal/errs"
 	"context"
-	"strings"
+	"errors"
 )
 
 type Selector[T any] struct {
+	builder
 	table string
-	model *Model
 	where []Predicate
-	sb    *strings.Builder
-	args  []any
 
-	db *DB
+	hints      []string
+	indexHints []indexHint
+
+	limit  int
+	offset int
+
+	sess Session
 }
 
-func NewSelector[T any](db *DB) *Selector[T] {
+func NewSelector[T any](sess Session) *Selector[T] {
 	return &Selector[T]{
-		sb: &strings.Builder{},
-		db: db,
+		builder: builder{
+			core: sess.getCore(),
+		},
+		sess: sess,
 	}
 }
 
+// Build 分片的模型查询会落到多个分片上的时候会返回错误, 此时只能用 Get 或者 GetMulti
 func (s *Selector[T]) Build() (*Query, error) {
-	// 是指针的话要先初始化好
-	//s.sb = &strings.Builder{}
+	qs, err := s.buildQueries(s.limit)
+	if err != nil {
+		return nil, err
+	}
+	switch len(qs) {
+	case 0:
+		return nil, errs.ErrShardingNoTarget
+	case 1:
+		return qs[0], nil
+	default:
+		return nil, errs.ErrShardingMultipleTargets
+	}
+}
+
+// buildQueries 不分片的模型只有一个查询, 分片的模型每个目标一个查询
+// limit 由调用者决定, Get 总是 1, 这样不会修改 Selector 本身
+func (s *Selector[T]) buildQueries(limit int) ([]*Query, error) {
 	var err error
-	s.model, err = s.db.r.Get(new(T))
+	s.model, err = s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	// 用户指定了表名就不分片了
+	if s.model.sharding == nil || s.table != "" {
+		q, err := s.build(s.table, "", limit)
+		if err != nil {
+			return nil, err
+		}
+		return []*Query{q}, nil
+	}
+
+	dsts, err := s.model.sharding.routeWhere(s.where)
 	if err != nil {
 		return nil, err
 	}
+	if len(dsts) > 1 && s.offset > 0 {
+		return nil, errs.ErrShardingOffset
+	}
+	res := make([]*Query, 0, len(dsts))
+	for _, dst := range dsts {
+		table, db := s.shardingTable(dst)
+		q, err := s.build(table, db, limit)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, q)
+	}
+	return res, nil
+}
+
+func (s *Selector[T]) build(table string, db string, limit int) (*Query, error) {
+	s.reset()
+	var err error
 	sb := s.sb
-	sb.WriteString("SELECT * FROM ")
+	sb.WriteString("SELECT")
+	if err = s.dialect.buildOptimizerHints(&s.builder, s.hints); err != nil {
+		return nil, err
+	}
+	sb.WriteString(" * FROM ")
 	// 我怎么把表名拿到 --> 反射
 	// 如果用户指定了表名, 我们就用表名
 	// 如果用户没有指定表名, 我们就用类型名
 	// 决策: 如果用户指定了表名, 就直接使用, 不会使用反引号; 否则使用反引号括起来
-	if s.table == "" {
-		sb.WriteByte('`')
-		sb.WriteString(s.model.tableName)
-		sb.WriteByte('`')
-	} else {
-		sb.WriteString(s.table)
-	}
-
-	if len(s.where) > 0 {
-		sb.WriteString(" WHERE ")
-		p := s.where[0]
-		for i := 1; i < len(s.where); i++ {
-			p = p.And(s.where[i])
-		}
+	if err = s.buildTable(table, new(T)); err != nil {
+		return nil, err
+	}
+	if err = s.dialect.buildIndexHints(&s.builder, s.indexHints); err != nil {
+		return nil, err
+	}
 
-		// p 本身就是一个Expression
-		if err := s.buildExpression(p); err != nil {
-			return nil, err
-		}
+	if err = s.buildWhere(s.where); err != nil {
+		return nil, err
+	}
+
+	if limit > 0 {
+		sb.WriteString(" LIMIT ?")
+		s.addArg(limit)
+	} else if s.offset > 0 {
+		// OFFSET 必须跟在 LIMIT 后面
+		sb.WriteString(" LIMIT ")
+		sb.WriteString(s.dialect.noLimit())
+	}
+	if s.offset > 0 {
+		sb.WriteString(" OFFSET ?")
+		s.addArg(s.offset)
 	}
 
 	sb.WriteByte(';')
 	return &Query{
 		SQL:  sb.String(),
 		Args: s.args,
+		DB:   db,
 	}, nil
 }
 
-func (s *Selector[T]) buildExpression(expr Expression) error {
-	switch exp := expr.(type) {
-	case nil:
-	case Predicate:
-		// 在这里处理 p
-		// p.left 构建好
-		// p.op 构建好
-		// p.right 构建好
-		_, ok := exp.left.(Predicate)
-		if ok {
-			s.sb.WriteByte('(')
-		}
-		if err := s.buildExpression(exp.left); err != nil {
-			return err
-		}
-		if ok {
-			s.sb.WriteByte(')')
-		}
-		s.sb.WriteByte(' ')
-		s.sb.WriteString(exp.op.String())
-		s.sb.WriteByte(' ')
-		_, ok = exp.right.(Predicate)
-		if ok {
-			s.sb.WriteByte('(')
-		}
-		if err := s.buildExpression(exp.right); err != nil {
-			return err
-		}
-		if ok {
-			s.sb.WriteByte(')')
-		}
-	case Column:
+func (s *Selector[T]) From(table string) *Selector[T] {
+	s.table = table
+	return s
+}
 
-		fd, ok := s.model.fileMap[exp.name]
-		// 字段不对或者 列不对
-		if !ok {
-			return errs.NewErrUnknownField(exp.name)
-		}
-		s.sb.WriteByte('`')
-		s.sb.WriteString(fd.colName)
-		s.sb.WriteByte('`')
-	case value:
-		s.sb.WriteByte('?')
-		s.addArg(exp.val)
-	default:
-		return errs.NewErrUnsupportedExpression(expr)
-	}
-	return nil
+func (s *Selector[T]) Where(ps ...Predicate) *Selector[T] {
+	s.where = ps
+	return s
 }
 
-func (s *Selector[T]) addArg(val any) {
-	if s.args == nil {
-		s.args = make([]any, 0, 8)
-	}
-	s.args = append(s.args, val)
+// Limit 小于等于 0 表示不限制
+func (s *Selector[T]) Limit(limit int) *Selector[T] {
+	s.limit = limit
+	return s
 }
 
-func (s *Selector[T]) From(table string) *Selector[T] {
-	s.table = table
+func (s *Selector[T]) Offset(offset int) *Selector[T] {
+	s.offset = offset
 	return s
 }
 
-func (s *Selector[T]) Where(ps ...Predicate) *Selector[T] {
-	s.where = ps
+// Hints 优化器提示, 例如 Hints("MAX_EXECUTION_TIME(1000)")
+// 只有 MySQL 方言支持, 其它方言会在 Build 的时候返回错误
+func (s *Selector[T]) Hints(hints ...string) *Selector[T] {
+	s.hints = append(s.hints, hints...)
+	return s
+}
+
+// UseIndex USE INDEX (`idx`), 多次调用会按调用顺序拼接
+func (s *Selector[T]) UseIndex(indexes ...string) *Selector[T] {
+	s.indexHints = appendIndexHint(s.indexHints, hintUseIndex, indexes)
+	return s
+}
+
+// ForceIndex FORCE INDEX (`idx`)
+func (s *Selector[T]) ForceIndex(indexes ...string) *Selector[T] {
+	s.indexHints = appendIndexHint(s.indexHints, hintForceIndex, indexes)
 	return s
 }
 
+// IgnoreIndex IGNORE INDEX (`idx`)
+func (s *Selector[T]) IgnoreIndex(indexes ...string) *Selector[T] {
+	s.indexHints = appendIndexHint(s.indexHints, hintIgnoreIndex, indexes)
+	return s
+}
+
+// Get 只会查询一行, 所以会带上 LIMIT 1, 但是不会修改 Selector 上的 Limit
+// 落到多个分片上的时候, 按顺序查询, 返回第一个找到的
 func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
-	//TODO implement me
-	panic("implement me")
+	s.ctx = ctx
+	qs, err := s.buildQueries(1)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range qs {
+		res := s.handle(ctx, s.newQueryContext(q, false), func(ctx context.Context, qc *QueryContext) *QueryResult {
+			t, err := s.get(ctx, qc.Query)
+			return &QueryResult{Result: t, Err: err}
+		})
+		if errors.Is(res.Err, ErrNoRows) {
+			continue
+		}
+		t, _ := res.Result.(*T)
+		return t, res.Err
+	}
+	return nil, ErrNoRows
+}
+
+func (s *Selector[T]) get(ctx context.Context, q *Query) (*T, error) {
+	rows, err := queryOn(ctx, s.sess, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoRows
+	}
+	t, err := scanRow[T](s.model, rows)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.afterFind(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
+// GetMulti 落到多个分片上的时候, 每个分片单独经过 Middleware, 结果按分片的顺序合并
 func (s *Selector[T]) GetMulti(ctx context.Context) ([]*T, error) {
-	//TODO implement me
-	panic("implement me")
+	s.ctx = ctx
+	qs, err := s.buildQueries(s.limit)
+	if err != nil {
+		return nil, err
+	}
+	var res []*T
+	for _, q := range qs {
+		qr := s.handle(ctx, s.newQueryContext(q, true), func(ctx context.Context, qc *QueryContext) *QueryResult {
+			ts, err := s.getMulti(ctx, qc.Query)
+			return &QueryResult{Result: ts, Err: err}
+		})
+		if qr.Err != nil {
+			return nil, qr.Err
+		}
+		ts, _ := qr.Result.([]*T)
+		if len(qs) == 1 {
+			return ts, nil
+		}
+		res = append(res, ts...)
+	}
+	// 每个分片都带了 LIMIT, 合并之后要再截断一次
+	if s.limit > 0 && len(res) > s.limit {
+		res = res[:s.limit]
+	}
+	if res == nil {
+		res = []*T{}
+	}
+	return res, nil
+}
+
+func (s *Selector[T]) getMulti(ctx context.Context, q *Query) ([]*T, error) {
+	rows, err := queryOn(ctx, s.sess, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make([]*T, 0, 8)
+	for rows.Next() {
+		t, err := scanRow[T](s.model, rows)
+		if err != nil {
+			return nil, err
+		}
+		if err = s.afterFind(ctx, t); err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+// afterFind 在 Middleware 里面执行, 所以缓存下来的结果已经执行过钩子了
+func (s *Selector[T]) afterFind(ctx context.Context, t *T) error {
+	if !s.model.hooks.afterFind {
+		return nil
+	}
+	return any(t).(AfterFinder).AfterFind(ctx, s.sess)
+}
+
+func (s *Selector[T]) newQueryContext(q *Query, multi bool) *QueryContext {
+	return &QueryContext{
+		Type:    OpSelect,
+		Builder: s,
+		Query:   q,
+		Model:   s.model,
+		sess:    s.sess,
+		multi:   multi,
+	}
 }
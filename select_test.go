@@ -2,6 +2,7 @@ package Go_ORM
 
 import (
 	"Go_ORM/internal/errs"
+	"context"
 	"database/sql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -11,6 +12,8 @@ import (
 func TestSelector_Build(t *testing.T) {
 	db, err := NewDB()
 	require.NoError(t, err)
+	sqliteDB, err := NewDB(DBWithDialect(SQLite))
+	require.NoError(t, err)
 	testCases := []struct {
 		name    string
 		builder QueryBuilder
@@ -109,6 +112,107 @@ func TestSelector_Build(t *testing.T) {
 			builder: NewSelector[TestModel](db).Where(C("Age").Eq(18).Or(C("xxxx").Eq("Tom"))),
 			wantErr: errs.NewErrUnknownField("xxxx"),
 		},
+
+		{
+			name:    "limit offset",
+			builder: NewSelector[TestModel](db).Where(C("Age").Eq(18)).Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` = ? LIMIT ? OFFSET ?;",
+				Args: []any{18, 10, 20},
+			},
+		},
+
+		{
+			name:    "offset only",
+			builder: NewSelector[TestModel](db).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT 18446744073709551615 OFFSET ?;",
+				Args: []any{20},
+			},
+		},
+
+		{
+			name:    "sqlite offset only",
+			builder: NewSelector[TestModel](sqliteDB).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT -1 OFFSET ?;",
+				Args: []any{20},
+			},
+		},
+
+		{
+			name:    "limit",
+			builder: NewSelector[TestModel](db).Limit(10),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT ?;",
+				Args: []any{10},
+			},
+		},
+
+		// 索引提示
+		{
+			name:    "use index",
+			builder: NewSelector[TestModel](db).UseIndex("idx_age", "idx_first_name").Where(C("Age").Eq(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` USE INDEX (`idx_age`,`idx_first_name`) WHERE `age` = ?;",
+				Args: []any{18},
+			},
+		},
+
+		{
+			name:    "force and ignore index",
+			builder: NewSelector[TestModel](db).ForceIndex("idx_age").IgnoreIndex("idx_first_name"),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` FORCE INDEX (`idx_age`) IGNORE INDEX (`idx_first_name`);",
+			},
+		},
+
+		{
+			name:    "empty index",
+			builder: NewSelector[TestModel](db).ForceIndex(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model`;",
+			},
+		},
+
+		{
+			name:    "index hint with from",
+			builder: NewSelector[TestModel](db).From("`test_db`.`test_model`").UseIndex("idx_age"),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_db`.`test_model` USE INDEX (`idx_age`);",
+			},
+		},
+
+		// 优化器提示
+		{
+			name:    "optimizer hints",
+			builder: NewSelector[TestModel](db).Hints("MAX_EXECUTION_TIME(1000)", "NO_ICP(test_model)").UseIndex("idx_age"),
+			wantQuery: &Query{
+				SQL: "SELECT /*+ MAX_EXECUTION_TIME(1000) NO_ICP(test_model) */ * FROM `test_model` USE INDEX (`idx_age`);",
+			},
+		},
+
+		// 其它方言不支持提示
+		{
+			name:    "sqlite index hint",
+			builder: NewSelector[TestModel](sqliteDB).UseIndex("idx_age"),
+			wantErr: errs.NewErrUnsupportedHint("sqlite"),
+		},
+
+		{
+			name:    "sqlite optimizer hints",
+			builder: NewSelector[TestModel](sqliteDB).Hints("MAX_EXECUTION_TIME(1000)"),
+			wantErr: errs.NewErrUnsupportedHint("sqlite"),
+		},
+
+		{
+			name:    "sqlite no hints",
+			builder: NewSelector[TestModel](sqliteDB).Where(C("Age").Eq(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` = ?;",
+				Args: []any{18},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -123,6 +227,110 @@ func TestSelector_Build(t *testing.T) {
 	}
 }
 
+func TestSelector_Get(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	_, err := NewInserter[TestModel](db).Values(
+		&TestModel{Id: 1, Age: 18, FirstName: "Tom", LastName: &sql.NullString{String: "Jerry", Valid: true}},
+		&TestModel{Id: 2, Age: 19, FirstName: "Jerry"},
+	).Exec(ctx)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		selector *Selector[TestModel]
+
+		wantRes *TestModel
+		wantErr error
+	}{
+		{
+			name:     "found",
+			selector: NewSelector[TestModel](db).Where(C("Id").Eq(1)),
+			wantRes: &TestModel{
+				Id:        1,
+				Age:       18,
+				FirstName: "Tom",
+				LastName:  &sql.NullString{String: "Jerry", Valid: true},
+			},
+		},
+
+		{
+			name:     "null column",
+			selector: NewSelector[TestModel](db).Where(C("Id").Eq(2)),
+			wantRes: &TestModel{
+				Id:        2,
+				Age:       19,
+				FirstName: "Jerry",
+			},
+		},
+
+		{
+			name:     "no rows",
+			selector: NewSelector[TestModel](db).Where(C("Id").Eq(3)),
+			wantErr:  ErrNoRows,
+		},
+
+		{
+			name:     "invalid column",
+			selector: NewSelector[TestModel](db).Where(C("xxxx").Eq(3)),
+			wantErr:  errs.NewErrUnknownField("xxxx"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.selector.Get(ctx)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func TestSelector_GetMulti(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	_, err := NewInserter[TestModel](db).Values(
+		&TestModel{Id: 1, Age: 18, FirstName: "Tom"},
+		&TestModel{Id: 2, Age: 18, FirstName: "Jerry"},
+		&TestModel{Id: 3, Age: 19, FirstName: "Bob"},
+	).Exec(ctx)
+	require.NoError(t, err)
+
+	res, err := NewSelector[TestModel](db).Where(C("Age").Eq(18)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{
+		{Id: 1, Age: 18, FirstName: "Tom"},
+		{Id: 2, Age: 18, FirstName: "Jerry"},
+	}, res)
+
+	res, err = NewSelector[TestModel](db).Where(C("Age").Eq(20)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{}, res)
+
+	// 只有 OFFSET
+	res, err = NewSelector[TestModel](db).Offset(1).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{
+		{Id: 2, Age: 18, FirstName: "Jerry"},
+		{Id: 3, Age: 19, FirstName: "Bob"},
+	}, res)
+
+	// Get 不会修改 Limit
+	s := NewSelector[TestModel](db).Limit(10)
+	tm, err := s.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, Age: 18, FirstName: "Tom"}, tm)
+	res, err = s.GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Len(t, res, 3)
+	q, err := s.Build()
+	require.NoError(t, err)
+	assert.Equal(t, []any{10}, q.Args)
+}
+
 type TestModel struct {
 	Id        int64
 	Age       int8
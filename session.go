@@ -0,0 +1,60 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Session 代表一个抽象的概念, 即会话
+// *DB 和 *Tx 都实现了它, 所以同一份查询代码既可以在事务里面跑, 也可以在事务外面跑
+type Session interface {
+	getCore() core
+	queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	execContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// core 是 DB 和 Tx 共享的部分
+type core struct {
+	r       *registry
+	dialect Dialect
+	mdls    []Middleware
+	// shardingDBs 分库对应的连接
+	shardingDBs map[string]*sql.DB
+	// clock 为 nil 的时候使用 time.Now
+	clock func() time.Time
+}
+
+// checkShardingTx 事务只能作用于一个连接
+func checkShardingTx(ctx context.Context, sess Session) error {
+	if _, ok := sess.(*Tx); ok {
+		return errs.ErrShardingInTx
+	}
+	if _, ok := TxFromContext(ctx); ok {
+		return errs.ErrShardingInTx
+	}
+	return nil
+}
+
+// queryOn Query.DB 不为空就在对应的分库上执行
+func queryOn(ctx context.Context, sess Session, q *Query) (*sql.Rows, error) {
+	if q.DB == "" {
+		return sess.queryContext(ctx, q.SQL, q.Args...)
+	}
+	if err := checkShardingTx(ctx, sess); err != nil {
+		return nil, err
+	}
+	return sess.getCore().shardingDBs[q.DB].QueryContext(ctx, q.SQL, q.Args...)
+}
+
+// execOn 和 queryOn 一样, Query.DB 不为空就在对应的分库上执行
+func execOn(ctx context.Context, sess Session, q *Query) (sql.Result, error) {
+	if q.DB == "" {
+		return sess.execContext(ctx, q.SQL, q.Args...)
+	}
+	if err := checkShardingTx(ctx, sess); err != nil {
+		return nil, err
+	}
+	return sess.getCore().shardingDBs[q.DB].ExecContext(ctx, q.SQL, q.Args...)
+}
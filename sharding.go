@@ -0,0 +1,257 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+)
+
+// Dst 分片的目标
+type Dst struct {
+	// DB 库名, 为空表示不分库
+	DB string
+	// Table 表名, 为空表示使用模型的表名
+	Table string
+}
+
+// ShardingAlgorithm 分片算法
+type ShardingAlgorithm interface {
+	// Sharding 根据分片键的值计算目标
+	Sharding(val any) (Dst, error)
+	// Broadcast 全部目标, 分片键没有被约束的时候就查询全部目标
+	Broadcast() []Dst
+}
+
+// HashShardingAlgorithm 哈希取模
+// 整数直接取模, 字符串先计算 fnv 哈希再取模
+// 库下标是 hash % DBSize, 表下标是 hash / DBSize % TableSize, 这样每个库里面的每张表都能分到数据
+type HashShardingAlgorithm struct {
+	// DBPattern 例如 "order_db_%d", 为空表示不分库
+	DBPattern string
+	DBSize    int
+	// TablePattern 例如 "order_tab_%d", 为空表示不分表
+	TablePattern string
+	TableSize    int
+}
+
+func (h HashShardingAlgorithm) Sharding(val any) (Dst, error) {
+	hash, err := h.hash(val)
+	if err != nil {
+		return Dst{}, err
+	}
+	dbSize, tableSize := h.sizes()
+	return h.dst(hash%dbSize, hash/dbSize%tableSize), nil
+}
+
+func (h HashShardingAlgorithm) Broadcast() []Dst {
+	dbSize, tableSize := h.sizes()
+	res := make([]Dst, 0, dbSize*tableSize)
+	for i := uint64(0); i < dbSize; i++ {
+		for j := uint64(0); j < tableSize; j++ {
+			res = append(res, h.dst(i, j))
+		}
+	}
+	return res
+}
+
+func (h HashShardingAlgorithm) sizes() (uint64, uint64) {
+	dbSize, tableSize := uint64(1), uint64(1)
+	if h.DBPattern != "" && h.DBSize > 0 {
+		dbSize = uint64(h.DBSize)
+	}
+	if h.TablePattern != "" && h.TableSize > 0 {
+		tableSize = uint64(h.TableSize)
+	}
+	return dbSize, tableSize
+}
+
+func (h HashShardingAlgorithm) dst(dbIdx uint64, tableIdx uint64) Dst {
+	var res Dst
+	if h.DBPattern != "" {
+		res.DB = fmt.Sprintf(h.DBPattern, dbIdx)
+	}
+	if h.TablePattern != "" {
+		res.Table = fmt.Sprintf(h.TablePattern, tableIdx)
+	}
+	return res
+}
+
+func (h HashShardingAlgorithm) hash(val any) (uint64, error) {
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		if i < 0 {
+			i = -i
+		}
+		return uint64(i), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.String:
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(v.String()))
+		return hash.Sum64(), nil
+	default:
+		return 0, errs.NewErrUnsupportedShardingValue(val)
+	}
+}
+
+type shardingConfig struct {
+	// key 分片键, 是字段名
+	key string
+	alg ShardingAlgorithm
+}
+
+// ModelWithSharding 声明分片键和分片算法, key 是字段名
+// Selector 可以查询多个分片, Inserter、Updater 和 Deleter 只能写入一个分片
+func ModelWithSharding(key string, alg ShardingAlgorithm) ModelOpt {
+	return func(m *Model) error {
+		if _, ok := m.fileMap[key]; !ok {
+			return errs.NewErrUnknownField(key)
+		}
+		m.sharding = &shardingConfig{key: key, alg: alg}
+		return nil
+	}
+}
+
+// DBWithShardingDBs 分库对应的连接, key 是 Dst.DB
+// 没有在这里注册的库, 会使用 `db`.`table` 的形式在主库上查询
+func DBWithShardingDBs(dbs map[string]*sql.DB) DBOption {
+	return func(db *DB) {
+		db.shardingDBs = dbs
+	}
+}
+
+// routeWhere 多个条件之间是 AND 的关系
+func (c *shardingConfig) routeWhere(where []Predicate) ([]Dst, error) {
+	if len(where) == 0 {
+		return c.route(nil)
+	}
+	p := where[0]
+	for i := 1; i < len(where); i++ {
+		p = p.And(where[i])
+	}
+	return c.route(p)
+}
+
+// shardingTable 注册了连接的分库, 在分库上执行; 否则用 `db`.`table` 在默认连接上执行
+// 返回的表名已经用反引号括起来了
+func (b *builder) shardingTable(dst Dst) (string, string) {
+	table := dst.Table
+	if table == "" {
+		table = b.model.tableName
+	}
+	table = "`" + table + "`"
+	if _, ok := b.shardingDBs[dst.DB]; ok || dst.DB == "" {
+		return table, dst.DB
+	}
+	return "`" + dst.DB + "`." + table, ""
+}
+
+// shardingWriteTarget 写操作只能落到一个分片上, 返回值和 shardingTable 一样
+// 模型没有分片或者用户指定了表名的时候, 直接返回 table
+func (b *builder) shardingWriteTarget(table string,
+	route func(c *shardingConfig) ([]Dst, error)) (string, string, error) {
+	if b.model.sharding == nil || table != "" {
+		return table, "", nil
+	}
+	dsts, err := route(b.model.sharding)
+	if err != nil {
+		return "", "", err
+	}
+	switch len(dsts) {
+	case 0:
+		return "", "", errs.ErrShardingNoTarget
+	case 1:
+		table, db := b.shardingTable(dsts[0])
+		return table, db, nil
+	default:
+		return "", "", errs.ErrShardingMultipleWriteTargets
+	}
+}
+
+// routeValues 每一行按照分片键的值计算目标
+func (c *shardingConfig) routeValues(m *Model, vals []reflect.Value) ([]Dst, error) {
+	fd := m.fileMap[c.key]
+	var res []Dst
+	for _, val := range vals {
+		dst, err := c.alg.Sharding(val.FieldByIndex(fd.index).Interface())
+		if err != nil {
+			return nil, err
+		}
+		res = unionDsts(res, []Dst{dst})
+	}
+	return res, nil
+}
+
+// route 分析 WHERE 条件, 找出需要查询的目标
+// AND 取交集, OR 取并集, 没有约束分片键的条件(包括 NOT)就是全部目标
+func (c *shardingConfig) route(expr Expression) ([]Dst, error) {
+	p, ok := expr.(Predicate)
+	if !ok {
+		return c.alg.Broadcast(), nil
+	}
+	switch p.op {
+	case opEq:
+		col, ok := p.left.(Column)
+		if !ok || col.name != c.key {
+			return c.alg.Broadcast(), nil
+		}
+		val, ok := p.right.(value)
+		if !ok {
+			return c.alg.Broadcast(), nil
+		}
+		dst, err := c.alg.Sharding(val.val)
+		if err != nil {
+			return nil, err
+		}
+		return []Dst{dst}, nil
+	case opAnd:
+		left, err := c.route(p.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := c.route(p.right)
+		if err != nil {
+			return nil, err
+		}
+		return intersectDsts(left, right), nil
+	case opOr:
+		left, err := c.route(p.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := c.route(p.right)
+		if err != nil {
+			return nil, err
+		}
+		return unionDsts(left, right), nil
+	default:
+		return c.alg.Broadcast(), nil
+	}
+}
+
+func intersectDsts(left []Dst, right []Dst) []Dst {
+	res := make([]Dst, 0, len(left))
+	for _, l := range left {
+		for _, r := range right {
+			if l == r {
+				res = append(res, l)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func unionDsts(left []Dst, right []Dst) []Dst {
+	res := append(make([]Dst, 0, len(left)+len(right)), left...)
+	for _, r := range right {
+		if len(intersectDsts([]Dst{r}, res)) == 0 {
+			res = append(res, r)
+		}
+	}
+	return res
+}
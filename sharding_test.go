@@ -0,0 +1,359 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+type Order struct {
+	Id     int64
+	UserId int64
+	Amount int64
+}
+
+func newShardingDB(t *testing.T, opts ...DBOption) *DB {
+	db, err := NewDB(opts...)
+	require.NoError(t, err)
+	_, err = db.Register(&Order{}, ModelWithSharding("UserId", HashShardingAlgorithm{
+		DBPattern:    "order_db_%d",
+		DBSize:       2,
+		TablePattern: "order_tab_%d",
+		TableSize:    3,
+	}))
+	require.NoError(t, err)
+	return db
+}
+
+func TestHashShardingAlgorithm(t *testing.T) {
+	alg := HashShardingAlgorithm{
+		DBPattern:    "order_db_%d",
+		DBSize:       2,
+		TablePattern: "order_tab_%d",
+		TableSize:    3,
+	}
+	dst, err := alg.Sharding(7)
+	require.NoError(t, err)
+	// 7 % 2 = 1, 7 / 2 % 3 = 0
+	assert.Equal(t, Dst{DB: "order_db_1", Table: "order_tab_0"}, dst)
+	dst, err = alg.Sharding(uint8(9))
+	require.NoError(t, err)
+	assert.Equal(t, Dst{DB: "order_db_1", Table: "order_tab_1"}, dst)
+	_, err = alg.Sharding("Tom")
+	require.NoError(t, err)
+	_, err = alg.Sharding(1.5)
+	assert.Equal(t, errs.NewErrUnsupportedShardingValue(1.5), err)
+	assert.Equal(t, 6, len(alg.Broadcast()))
+
+	// 只分表
+	tableOnly := HashShardingAlgorithm{TablePattern: "order_tab_%d", TableSize: 3}
+	dst, err = tableOnly.Sharding(7)
+	require.NoError(t, err)
+	assert.Equal(t, Dst{Table: "order_tab_1"}, dst)
+	assert.Equal(t, []Dst{{Table: "order_tab_0"}, {Table: "order_tab_1"}, {Table: "order_tab_2"}},
+		tableOnly.Broadcast())
+}
+
+func TestSelector_Build_Sharding(t *testing.T) {
+	db := newShardingDB(t)
+	testCases := []struct {
+		name    string
+		builder *Selector[Order]
+
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "sharding key",
+			builder: NewSelector[Order](db).Where(C("UserId").Eq(7)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `order_db_1`.`order_tab_0` WHERE `user_id` = ?;",
+				Args: []any{7},
+			},
+		},
+
+		{
+			name:    "and",
+			builder: NewSelector[Order](db).Where(C("UserId").Eq(7), C("Id").Eq(12)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `order_db_1`.`order_tab_0` WHERE (`user_id` = ?) AND (`id` = ?);",
+				Args: []any{7, 12},
+			},
+		},
+
+		{
+			// 7 和 13 都落到 order_db_1.order_tab_0
+			name:    "or same target",
+			builder: NewSelector[Order](db).Where(C("UserId").Eq(7).Or(C("UserId").Eq(13))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `order_db_1`.`order_tab_0` WHERE (`user_id` = ?) OR (`user_id` = ?);",
+				Args: []any{7, 13},
+			},
+		},
+
+		{
+			name:    "or different targets",
+			builder: NewSelector[Order](db).Where(C("UserId").Eq(7).Or(C("UserId").Eq(8))),
+			wantErr: errs.ErrShardingMultipleTargets,
+		},
+
+		{
+			name:    "broadcast",
+			builder: NewSelector[Order](db).Where(C("Id").Eq(12)),
+			wantErr: errs.ErrShardingMultipleTargets,
+		},
+
+		{
+			name:    "not",
+			builder: NewSelector[Order](db).Where(Not(C("UserId").Eq(7))),
+			wantErr: errs.ErrShardingMultipleTargets,
+		},
+
+		{
+			name:    "no target",
+			builder: NewSelector[Order](db).Where(C("UserId").Eq(7), C("UserId").Eq(8)),
+			wantErr: errs.ErrShardingNoTarget,
+		},
+
+		{
+			name:    "invalid value",
+			builder: NewSelector[Order](db).Where(C("UserId").Eq(1.5)),
+			wantErr: errs.NewErrUnsupportedShardingValue(1.5),
+		},
+
+		{
+			// 用户指定了表名就不分片
+			name:    "from",
+			builder: NewSelector[Order](db).From("`order`"),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `order`;",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, query)
+		})
+	}
+}
+
+func TestWriters_Build_Sharding(t *testing.T) {
+	db := newShardingDB(t)
+	testCases := []struct {
+		name    string
+		builder QueryBuilder
+
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "insert",
+			builder: NewInserter[Order](db).Values(&Order{Id: 1, UserId: 7}, &Order{Id: 2, UserId: 13}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `order_db_1`.`order_tab_0`(`id`,`user_id`,`amount`) VALUES (?,?,?),(?,?,?);",
+				Args: []any{int64(1), int64(7), int64(0), int64(2), int64(13), int64(0)},
+			},
+		},
+
+		{
+			name:    "insert multiple targets",
+			builder: NewInserter[Order](db).Values(&Order{Id: 1, UserId: 7}, &Order{Id: 2, UserId: 8}),
+			wantErr: errs.ErrShardingMultipleWriteTargets,
+		},
+
+		{
+			name:    "update",
+			builder: NewUpdater[Order](db).Set(Assign("Id", 2)).Where(C("UserId").Eq(7)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `order_db_1`.`order_tab_0` SET `id`=? WHERE `user_id` = ?;",
+				Args: []any{2, 7},
+			},
+		},
+
+		{
+			name:    "update broadcast",
+			builder: NewUpdater[Order](db).Set(Assign("UserId", 8)),
+			wantErr: errs.ErrShardingMultipleWriteTargets,
+		},
+
+		{
+			name:    "update sharding key",
+			builder: NewUpdater[Order](db).Set(Assign("UserId", 8)).Where(C("UserId").Eq(7)),
+			wantErr: errs.ErrShardingKeyUpdate,
+		},
+
+		{
+			name:    "delete",
+			builder: NewDeleter[Order](db).Where(C("UserId").Eq(7), C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `order_db_1`.`order_tab_0` WHERE (`user_id` = ?) AND (`id` = ?);",
+				Args: []any{7, 1},
+			},
+		},
+
+		{
+			name:    "delete broadcast",
+			builder: NewDeleter[Order](db).Where(C("Id").Eq(1)),
+			wantErr: errs.ErrShardingMultipleWriteTargets,
+		},
+
+		{
+			name:    "delete no target",
+			builder: NewDeleter[Order](db).Where(C("UserId").Eq(7), C("UserId").Eq(8)),
+			wantErr: errs.ErrShardingNoTarget,
+		},
+
+		{
+			// 用户指定了表名就不分片
+			name:    "delete from",
+			builder: NewDeleter[Order](db).From("`order`").Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `order` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, query)
+		})
+	}
+}
+
+// newShardingSQLiteDB 两个库, 每个库三张表
+func newShardingSQLiteDB(t *testing.T) (*DB, map[string]*sql.DB) {
+	shardingDBs := make(map[string]*sql.DB, 2)
+	for i := 0; i < 2; i++ {
+		name := fmt.Sprintf("order_db_%d", i)
+		sdb, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), name+".db"))
+		require.NoError(t, err)
+		for j := 0; j < 3; j++ {
+			_, err = sdb.Exec(fmt.Sprintf("CREATE TABLE order_tab_%d(id INTEGER PRIMARY KEY, user_id INTEGER, amount INTEGER NOT NULL DEFAULT 0)", j))
+			require.NoError(t, err)
+		}
+		shardingDBs[name] = sdb
+		t.Cleanup(func() {
+			_ = sdb.Close()
+		})
+	}
+	return newShardingDB(t, DBWithDialect(SQLite), DBWithShardingDBs(shardingDBs)), shardingDBs
+}
+
+func TestWriters_Exec_Sharding(t *testing.T) {
+	db, shardingDBs := newShardingSQLiteDB(t)
+	ctx := context.Background()
+	count := func(dbName, table string) int {
+		var cnt int
+		err := shardingDBs[dbName].QueryRow("SELECT COUNT(*) FROM " + table).Scan(&cnt)
+		require.NoError(t, err)
+		return cnt
+	}
+
+	// 7 和 13 都落到 order_db_1.order_tab_0
+	_, err := NewInserter[Order](db).Values(&Order{Id: 1, UserId: 7}, &Order{Id: 2, UserId: 13}).Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count("order_db_1", "order_tab_0"))
+
+	// 分片键加到条件里面, 不会被更新
+	_, err = Update[Order](ctx, db, &Order{Id: 1, UserId: 7, Amount: 100})
+	require.NoError(t, err)
+	o, err := NewSelector[Order](db).Where(C("UserId").Eq(7), C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Order{Id: 1, UserId: 7, Amount: 100}, o)
+
+	_, err = DeleteByID[Order](ctx, db, 1)
+	assert.Equal(t, errs.ErrShardingMultipleWriteTargets, err)
+	res, err := NewDeleter[Order](db).Where(C("UserId").Eq(7), C("Id").Eq(1)).Exec(ctx)
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.Equal(t, 1, count("order_db_1", "order_tab_0"))
+}
+
+func TestSelector_GetMulti_Sharding(t *testing.T) {
+	// 每个分片放一个订单
+	db, shardingDBs := newShardingSQLiteDB(t)
+	for userId := int64(0); userId < 6; userId++ {
+		dst, err := HashShardingAlgorithm{
+			DBPattern: "order_db_%d", DBSize: 2, TablePattern: "order_tab_%d", TableSize: 3,
+		}.Sharding(userId)
+		require.NoError(t, err)
+		_, err = shardingDBs[dst.DB].Exec(
+			fmt.Sprintf("INSERT INTO %s(id, user_id) VALUES (?, ?)", dst.Table), userId+100, userId)
+		require.NoError(t, err)
+	}
+	ctx := context.Background()
+
+	// 命中单个分片
+	res, err := NewSelector[Order](db).Where(C("UserId").Eq(int64(3))).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*Order{{Id: 103, UserId: 3}}, res)
+	o, err := NewSelector[Order](db).Where(C("UserId").Eq(int64(4))).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Order{Id: 104, UserId: 4}, o)
+
+	// 广播, 每个分片经过一次 Middleware
+	var queries []string
+	db.mdls = []Middleware{func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			queries = append(queries, qc.Query.DB+" "+qc.Query.SQL)
+			return next(ctx, qc)
+		}
+	}}
+	res, err = NewSelector[Order](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*Order{
+		{Id: 100, UserId: 0}, {Id: 101, UserId: 1}, {Id: 102, UserId: 2},
+		{Id: 103, UserId: 3}, {Id: 104, UserId: 4}, {Id: 105, UserId: 5},
+	}, res)
+	assert.Equal(t, []string{
+		"order_db_0 SELECT * FROM `order_tab_0`;",
+		"order_db_0 SELECT * FROM `order_tab_1`;",
+		"order_db_0 SELECT * FROM `order_tab_2`;",
+		"order_db_1 SELECT * FROM `order_tab_0`;",
+		"order_db_1 SELECT * FROM `order_tab_1`;",
+		"order_db_1 SELECT * FROM `order_tab_2`;",
+	}, queries)
+
+	// OR 落到两个分片
+	res, err = NewSelector[Order](db).Where(C("UserId").Eq(int64(1)).Or(C("UserId").Eq(int64(2)))).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*Order{{Id: 101, UserId: 1}, {Id: 102, UserId: 2}}, res)
+
+	// 跨分片的 LIMIT
+	res, err = NewSelector[Order](db).Limit(4).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, len(res))
+	_, err = NewSelector[Order](db).Limit(4).Offset(1).GetMulti(ctx)
+	assert.Equal(t, errs.ErrShardingOffset, err)
+
+	// 跨分片的 Get
+	o, err = NewSelector[Order](db).Where(C("Id").Eq(105)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Order{Id: 105, UserId: 5}, o)
+	_, err = NewSelector[Order](db).Where(C("Id").Eq(106)).Get(ctx)
+	assert.Equal(t, ErrNoRows, err)
+
+	// 没有命中任何分片
+	res, err = NewSelector[Order](db).Where(C("UserId").Eq(int64(1)), C("UserId").Eq(int64(2))).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*Order{}, res)
+}
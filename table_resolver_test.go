@@ -0,0 +1,161 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type tenantKey struct{}
+
+// tenantResolver 按照 ctx 里面的租户选择表, 没有租户就用模型的表名
+func tenantResolver(ctx context.Context, entity any) (string, error) {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	if tenant == "" {
+		return "", nil
+	}
+	if tenant == "invalid" {
+		return "", errors.New("invalid tenant")
+	}
+	return tenant + "_test_model", nil
+}
+
+func TestModelWithTableNameResolver_Build(t *testing.T) {
+	db := MustNewDB()
+	_, err := db.Register(&TestModel{}, ModelWithTableNameResolver(
+		func(ctx context.Context, entity any) (string, error) {
+			// Inserter 和 Updater 可以按照实体选择表
+			if tm, ok := entity.(*TestModel); ok && tm.Age > 0 {
+				return "adult_test_model", nil
+			}
+			return "", nil
+		}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		builder QueryBuilder
+
+		wantQuery *Query
+	}{
+		{
+			name:    "select",
+			builder: NewSelector[TestModel](db),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model`;",
+			},
+		},
+		{
+			name:    "insert",
+			builder: NewInserter[TestModel](db).Values(&TestModel{Id: 1, Age: 18}).Columns("Id", "Age"),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `adult_test_model`(`id`,`age`) VALUES (?,?);",
+				Args: []any{int64(1), int8(18)},
+			},
+		},
+		{
+			name:    "update",
+			builder: NewUpdater[TestModel](db).Update(&TestModel{Age: 18}).Set(C("Age")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `adult_test_model` SET `age`=?;",
+				Args: []any{int8(18)},
+			},
+		},
+		{
+			name:    "update zero",
+			builder: NewUpdater[TestModel](db).Set(C("Age")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?;",
+				Args: []any{int8(0)},
+			},
+		},
+		{
+			name:    "from",
+			builder: NewInserter[TestModel](db).Values(&TestModel{Age: 18}).Columns("Age").From("`t`"),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `t`(`age`) VALUES (?);",
+				Args: []any{int8(18)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+// PartOrder 按照月份分表
+type PartOrder struct {
+	Id    int64
+	Month string
+}
+
+func TestModelWithTableNameResolver_InsertRows(t *testing.T) {
+	db := MustNewDB()
+	_, err := db.Register(&PartOrder{}, ModelWithTableNameResolver(
+		func(ctx context.Context, entity any) (string, error) {
+			if o, ok := entity.(*PartOrder); ok && o.Month != "" {
+				return "orders_" + o.Month, nil
+			}
+			return "", nil
+		}))
+	require.NoError(t, err)
+
+	// 同一个月的多行数据
+	q, err := NewInserter[PartOrder](db).Values(
+		&PartOrder{Id: 1, Month: "2026_09"}, &PartOrder{Id: 2, Month: "2026_09"}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "INSERT INTO `orders_2026_09`(`id`,`month`) VALUES (?,?),(?,?);",
+		Args: []any{int64(1), "2026_09", int64(2), "2026_09"},
+	}, q)
+
+	// 不同月份的数据不能插入到第一行的表里面
+	_, err = NewInserter[PartOrder](db).Values(
+		&PartOrder{Id: 1, Month: "2026_09"}, &PartOrder{Id: 2, Month: "2026_10"}).Build()
+	assert.Equal(t, errs.ErrInsertMultipleTables, err)
+
+	// 指定了表名就不检查
+	q, err = NewInserter[PartOrder](db).From("`orders`").Values(
+		&PartOrder{Id: 1, Month: "2026_09"}, &PartOrder{Id: 2, Month: "2026_10"}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `orders`(`id`,`month`) VALUES (?,?),(?,?);", q.SQL)
+}
+
+func TestModelWithTableNameResolver_Exec(t *testing.T) {
+	db := newSQLiteDB(t)
+	_, err := db.Register(&TestModel{}, ModelWithTableNameResolver(tenantResolver))
+	require.NoError(t, err)
+	_, err = db.db.Exec("CREATE TABLE tenant1_test_model AS SELECT * FROM test_model WHERE 1 = 0")
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "tenant1")
+	_, err = NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+	require.NoError(t, err)
+	_, err = NewUpdater[TestModel](db).Set(Assign("Age", 18)).Where(C("Id").Eq(1)).Exec(ctx)
+	require.NoError(t, err)
+
+	tm, err := NewSelector[TestModel](db).Where(C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, Age: 18, FirstName: "Tom"}, tm)
+	// 没有租户的时候查的是 test_model
+	_, err = NewSelector[TestModel](db).Where(C("Id").Eq(1)).Get(context.Background())
+	assert.Equal(t, ErrNoRows, err)
+
+	res, err := NewDeleter[TestModel](db).Where(C("Id").Eq(1)).Exec(ctx)
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	ctx = context.WithValue(context.Background(), tenantKey{}, "invalid")
+	_, err = NewSelector[TestModel](db).GetMulti(ctx)
+	assert.Equal(t, errors.New("invalid tenant"), err)
+}
@@ -0,0 +1,127 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+type Tx struct {
+	tx *sql.Tx
+	db *DB
+
+	// savepoint 不为空说明这是一个嵌套事务, 提交和回滚都只作用于这个保存点
+	savepoint string
+	// depth 嵌套的层数, 用来生成保存点的名字
+	depth int
+	// parent 嵌套事务的外层事务
+	parent *Tx
+	// onCommit 提交成功之后执行, 嵌套事务提交的时候交给外层事务
+	onCommit []func()
+}
+
+// OnCommit 注册 fn, 最外层的事务提交成功之后才会执行, 回滚了就不会执行
+// 例如写操作要等到提交之后再让缓存失效
+func (t *Tx) OnCommit(fn func()) {
+	t.onCommit = append(t.onCommit, fn)
+}
+
+// Commit 嵌套事务的 Commit 只会释放保存点, 真正的提交由最外层事务完成
+func (t *Tx) Commit() error {
+	if t.savepoint == "" {
+		if err := t.tx.Commit(); err != nil {
+			return err
+		}
+		for _, fn := range t.onCommit {
+			fn()
+		}
+		return nil
+	}
+	_, err := t.tx.ExecContext(context.Background(), t.db.dialect.releaseSavepoint(t.savepoint))
+	if err == nil {
+		t.parent.onCommit = append(t.parent.onCommit, t.onCommit...)
+	}
+	return err
+}
+
+// Rollback 嵌套事务的 Rollback 只会回滚到保存点, 外层事务不受影响
+func (t *Tx) Rollback() error {
+	if t.savepoint == "" {
+		return t.tx.Rollback()
+	}
+	ctx := context.Background()
+	_, err := t.tx.ExecContext(ctx, t.db.dialect.rollbackToSavepoint(t.savepoint))
+	if err != nil {
+		return err
+	}
+	// 回滚之后保存点还在, 要释放掉
+	_, err = t.tx.ExecContext(ctx, t.db.dialect.releaseSavepoint(t.savepoint))
+	return err
+}
+
+// DoTx 在当前事务里面开启一个嵌套事务
+// 嵌套事务是通过 SAVEPOINT 实现的, fn 出错只会回滚 fn 里面的操作
+func (t *Tx) DoTx(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error) error {
+	depth := t.depth + 1
+	name := "sp_" + strconv.Itoa(depth)
+	_, err := t.tx.ExecContext(ctx, t.db.dialect.savepoint(name))
+	if err != nil {
+		return err
+	}
+	return doTx(ctx, &Tx{
+		tx:        t.tx,
+		db:        t.db,
+		savepoint: name,
+		depth:     depth,
+		parent:    t,
+	}, fn)
+}
+
+// doTx DB.DoTx 和 Tx.DoTx 的公共部分
+// 传给 fn 的 ctx 里面带上了 tx, 所以 fn 里面用 DB 创建的查询也会加入这个事务
+func doTx(ctx context.Context, tx *Tx,
+	fn func(ctx context.Context, tx *Tx) error) (err error) {
+	ctx = ContextWithTx(ctx, tx)
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				err = errs.NewErrFailedToRollbackTx(err, rbErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(ctx, tx)
+}
+
+type txKey struct{}
+
+// ContextWithTx 把 tx 放进 ctx 里面
+// 之后用 *DB 创建的 Selector 之类的, 用这个 ctx 执行的时候就会自动加入这个事务
+func ContextWithTx(ctx context.Context, tx *Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext 取出 ctx 里面的事务
+func TxFromContext(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Tx)
+	return tx, ok
+}
+
+func (t *Tx) getCore() core {
+	return t.db.core
+}
+
+func (t *Tx) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t *Tx) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,322 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// insertAndCount 同一份代码, 传入 *DB 或 *Tx 都能执行
+func insertAndCount(t *testing.T, ctx context.Context, sess Session, tm *TestModel) int {
+	_, err := NewInserter[TestModel](sess).Values(tm).Exec(ctx)
+	require.NoError(t, err)
+	res, err := NewSelector[TestModel](sess).GetMulti(ctx)
+	require.NoError(t, err)
+	return len(res)
+}
+
+func TestTx_Commit(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, insertAndCount(t, ctx, tx, &TestModel{Id: 1, FirstName: "Tom"}))
+	require.NoError(t, tx.Commit())
+
+	tm, err := NewSelector[TestModel](db).Where(C("Id").Eq(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Tom", tm.FirstName)
+	// 事务外面也是同一份代码
+	assert.Equal(t, 2, insertAndCount(t, ctx, db, &TestModel{Id: 2, FirstName: "Jerry"}))
+}
+
+func TestTx_Rollback(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, insertAndCount(t, ctx, tx, &TestModel{Id: 1, FirstName: "Tom"}))
+	_, err = NewUpdater[TestModel](tx).Set(Assign("Age", 18)).Exec(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	_, err = NewSelector[TestModel](db).Where(C("Id").Eq(1)).Get(ctx)
+	assert.Equal(t, ErrNoRows, err)
+}
+
+func TestDB_DoTx(t *testing.T) {
+	bizErr := errors.New("biz error")
+	testCases := []struct {
+		name string
+		fn   func(ctx context.Context, tx *Tx) error
+
+		wantErr   error
+		wantPanic any
+		wantCount int
+	}{
+		{
+			name: "commit",
+			fn: func(ctx context.Context, tx *Tx) error {
+				_, err := NewInserter[TestModel](tx).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+				return err
+			},
+			wantCount: 1,
+		},
+
+		{
+			name: "rollback on error",
+			fn: func(ctx context.Context, tx *Tx) error {
+				_, err := NewInserter[TestModel](tx).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+				require.NoError(t, err)
+				return bizErr
+			},
+			wantErr:   bizErr,
+			wantCount: 0,
+		},
+
+		{
+			name: "rollback on panic",
+			fn: func(ctx context.Context, tx *Tx) error {
+				_, err := NewInserter[TestModel](tx).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+				require.NoError(t, err)
+				panic("biz panic")
+			},
+			wantPanic: "biz panic",
+			wantCount: 0,
+		},
+
+		{
+			name: "rollback failed",
+			fn: func(ctx context.Context, tx *Tx) error {
+				// 用户自己提前回滚了, DoTx 再回滚就会失败
+				require.NoError(t, tx.Rollback())
+				return bizErr
+			},
+			wantErr:   errs.NewErrFailedToRollbackTx(bizErr, sql.ErrTxDone),
+			wantCount: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newSQLiteDB(t)
+			ctx := context.Background()
+			var err error
+			func() {
+				defer func() {
+					assert.Equal(t, tc.wantPanic, recover())
+				}()
+				err = db.DoTx(ctx, tc.fn, nil)
+			}()
+			assert.Equal(t, tc.wantErr, err)
+			if tc.wantErr != nil {
+				// 回滚失败也要能判断出业务错误
+				assert.True(t, errors.Is(err, bizErr))
+			}
+
+			res, err := NewSelector[TestModel](db).GetMulti(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCount, len(res))
+		})
+	}
+}
+
+func TestTx_DoTx(t *testing.T) {
+	bizErr := errors.New("biz error")
+	insert := func(ctx context.Context, tx *Tx, id int64) {
+		_, err := NewInserter[TestModel](tx).Values(&TestModel{Id: id, FirstName: "Tom"}).Exec(ctx)
+		require.NoError(t, err)
+	}
+	testCases := []struct {
+		name  string
+		inner func(ctx context.Context, tx *Tx) error
+
+		wantErr error
+		wantIds []int64
+	}{
+		{
+			name: "release",
+			inner: func(ctx context.Context, tx *Tx) error {
+				insert(ctx, tx, 2)
+				return nil
+			},
+			wantIds: []int64{1, 2, 3},
+		},
+
+		{
+			name: "partial rollback",
+			inner: func(ctx context.Context, tx *Tx) error {
+				insert(ctx, tx, 2)
+				return bizErr
+			},
+			wantErr: bizErr,
+			wantIds: []int64{1, 3},
+		},
+
+		{
+			name: "nested twice",
+			inner: func(ctx context.Context, tx *Tx) error {
+				insert(ctx, tx, 2)
+				// 最里层失败不影响中间层
+				err := tx.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+					insert(ctx, tx, 4)
+					return bizErr
+				})
+				assert.Equal(t, bizErr, err)
+				return tx.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+					insert(ctx, tx, 5)
+					return nil
+				})
+			},
+			wantIds: []int64{1, 2, 3, 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newSQLiteDB(t)
+			ctx := context.Background()
+			err := db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+				insert(ctx, tx, 1)
+				err := tx.DoTx(ctx, tc.inner)
+				assert.Equal(t, tc.wantErr, err)
+				// 嵌套事务失败了, 外层事务还能继续用
+				insert(ctx, tx, 3)
+				return nil
+			}, nil)
+			require.NoError(t, err)
+
+			res, err := NewSelector[TestModel](db).GetMulti(ctx)
+			require.NoError(t, err)
+			ids := make([]int64, 0, len(res))
+			for _, r := range res {
+				ids = append(ids, r.Id)
+			}
+			assert.Equal(t, tc.wantIds, ids)
+		})
+	}
+}
+
+func TestTx_DoTx_Panic(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	err := db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		_, err := NewInserter[TestModel](tx).Values(&TestModel{Id: 1, FirstName: "Tom"}).Exec(ctx)
+		require.NoError(t, err)
+		assert.Panics(t, func() {
+			_ = tx.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+				_, err := NewInserter[TestModel](tx).Values(&TestModel{Id: 2, FirstName: "Jerry"}).Exec(ctx)
+				require.NoError(t, err)
+				panic("biz panic")
+			})
+		})
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	res, err := NewSelector[TestModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{{Id: 1, FirstName: "Tom"}}, res)
+}
+
+// userRepo 模拟业务里面的仓储, 完全不知道事务的存在
+type userRepo struct {
+	db *DB
+}
+
+func (r *userRepo) create(ctx context.Context, tm *TestModel) error {
+	_, err := NewInserter[TestModel](r.db).Values(tm).Exec(ctx)
+	return err
+}
+
+func TestContextWithTx(t *testing.T) {
+	db := newSQLiteDB(t)
+	repo := &userRepo{db: db}
+	ctx := context.Background()
+
+	// 手动放进 ctx
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	txCtx := ContextWithTx(ctx, tx)
+	require.NoError(t, repo.create(txCtx, &TestModel{Id: 1, FirstName: "Tom"}))
+	// 事务里面能看到
+	res, err := NewSelector[TestModel](db).GetMulti(txCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	require.NoError(t, tx.Rollback())
+
+	res, err = NewSelector[TestModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(res))
+
+	// DoTx 会自动放进 ctx, 嵌套调用 DoTx 会变成保存点
+	bizErr := errors.New("biz error")
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		require.NoError(t, repo.create(ctx, &TestModel{Id: 1, FirstName: "Tom"}))
+		err := db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+			require.NoError(t, repo.create(ctx, &TestModel{Id: 2, FirstName: "Jerry"}))
+			return bizErr
+		}, nil)
+		assert.Equal(t, bizErr, err)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	res, err = NewSelector[TestModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{{Id: 1, FirstName: "Tom"}}, res)
+
+	// 别的 DB 开启的事务不会被加入
+	other := newSQLiteDB(t)
+	otherTx, err := other.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = otherTx.Rollback()
+	}()
+	require.NoError(t, repo.create(ContextWithTx(ctx, otherTx), &TestModel{Id: 3, FirstName: "Bob"}))
+	res, err = NewSelector[TestModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(res))
+}
+
+func TestTx_OnCommit(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	var called []string
+	err := db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(func() {
+			called = append(called, "outer")
+		})
+		_ = tx.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+			tx.OnCommit(func() {
+				called = append(called, "rollback")
+			})
+			return errors.New("rollback")
+		})
+		err := tx.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+			tx.OnCommit(func() {
+				called = append(called, "nested")
+			})
+			return nil
+		})
+		assert.Empty(t, called)
+		return err
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "nested"}, called)
+
+	called = nil
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	tx.OnCommit(func() {
+		called = append(called, "outer")
+	})
+	require.NoError(t, tx.Rollback())
+	assert.Empty(t, called)
+}
@@ -1,10 +1,14 @@
 package Go_ORM
 
 import (
+	"Go_ORM/internal/errs"
 	"context"
 	"database/sql"
 )
 
+// ErrNoRows Get 查询不到数据的时候返回
+var ErrNoRows = errs.ErrNoRows
+
 // Querier 用于 SELECT 语句
 type Querier[T any] interface {
 	Get(ctx context.Context) (*T, error)
@@ -25,6 +29,8 @@ type QueryBuilder interface {
 type Query struct {
 	SQL  string
 	Args []any
+	// DB 分库的库名, 为空表示在默认的连接上执行
+	DB string
 }
 
 type TableName interface {
@@ -0,0 +1,201 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+type Updater[T any] struct {
+	builder
+	table   string
+	val     *T
+	assigns []Assignable
+	where   []Predicate
+
+	hints      []string
+	indexHints []indexHint
+
+	sess Session
+}
+
+func NewUpdater[T any](sess Session) *Updater[T] {
+	return &Updater[T]{
+		builder: builder{
+			core: sess.getCore(),
+		},
+		sess: sess,
+	}
+}
+
+func (u *Updater[T]) Build() (*Query, error) {
+	if len(u.assigns) == 0 {
+		return nil, errs.ErrNoUpdatedColumns
+	}
+	u.reset()
+	var err error
+	u.model, err = u.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	table, db, err := u.shardingWriteTarget(u.table, func(c *shardingConfig) ([]Dst, error) {
+		return c.routeWhere(u.where)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sb := u.sb
+	sb.WriteString("UPDATE")
+	if err = u.dialect.buildOptimizerHints(&u.builder, u.hints); err != nil {
+		return nil, err
+	}
+	sb.WriteByte(' ')
+	entity := u.val
+	if entity == nil {
+		entity = new(T)
+	}
+	if err = u.buildTable(table, entity); err != nil {
+		return nil, err
+	}
+	if err = u.dialect.buildIndexHints(&u.builder, u.indexHints); err != nil {
+		return nil, err
+	}
+
+	sb.WriteString(" SET ")
+	// 用户传入的实体, 没有传就用零值
+	val := reflect.ValueOf(entity).Elem()
+	// 自动更新时间总是当前时间, 实体里面的值也会被修改
+	now := u.now()
+	for _, fd := range u.model.fields {
+		if fd.autoUpdateTime {
+			val.FieldByIndex(fd.index).Set(fd.autoTimeValue(now))
+		}
+	}
+	assigned := make(map[string]struct{}, len(u.assigns))
+	for i, a := range u.assigns {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		switch assign := a.(type) {
+		case Column:
+			if err = u.checkShardingKey(assign.name); err != nil {
+				return nil, err
+			}
+			if err = u.buildColumn(assign.name); err != nil {
+				return nil, err
+			}
+			sb.WriteString("=?")
+			u.addArg(val.FieldByIndex(u.model.fileMap[assign.name].index).Interface())
+			assigned[assign.name] = struct{}{}
+		case Assignment:
+			if err = u.checkShardingKey(assign.column); err != nil {
+				return nil, err
+			}
+			if err = u.buildColumn(assign.column); err != nil {
+				return nil, err
+			}
+			sb.WriteString("=?")
+			u.addArg(assign.val)
+			assigned[assign.column] = struct{}{}
+		default:
+			return nil, errs.NewErrUnsupportedAssignable(a)
+		}
+	}
+	// 没有指定的自动更新时间也要更新
+	for _, fd := range u.model.fields {
+		if _, ok := assigned[fd.name]; ok || !fd.autoUpdateTime {
+			continue
+		}
+		sb.WriteByte(',')
+		u.quote(fd.colName)
+		sb.WriteString("=?")
+		u.addArg(val.FieldByIndex(fd.index).Interface())
+	}
+
+	if err = u.buildWhere(u.where); err != nil {
+		return nil, err
+	}
+
+	sb.WriteByte(';')
+	return &Query{
+		SQL:  sb.String(),
+		Args: u.args,
+		DB:   db,
+	}, nil
+}
+
+// checkShardingKey 修改分片键会让数据落到别的分片上, 所以不允许
+func (u *Updater[T]) checkShardingKey(name string) error {
+	if u.table == "" && u.model.sharding != nil && u.model.sharding.key == name {
+		return errs.ErrShardingKeyUpdate
+	}
+	return nil
+}
+
+func (u *Updater[T]) From(table string) *Updater[T] {
+	u.table = table
+	return u
+}
+
+// Update 指定实体, Set(C("Age")) 的时候会从这里取值
+func (u *Updater[T]) Update(val *T) *Updater[T] {
+	u.val = val
+	return u
+}
+
+// Set 指定要更新的列
+// Set(C("Age"), Assign("FirstName", "Tom"))
+func (u *Updater[T]) Set(assigns ...Assignable) *Updater[T] {
+	u.assigns = assigns
+	return u
+}
+
+func (u *Updater[T]) Where(ps ...Predicate) *Updater[T] {
+	u.where = ps
+	return u
+}
+
+// Hints 优化器提示
+func (u *Updater[T]) Hints(hints ...string) *Updater[T] {
+	u.hints = append(u.hints, hints...)
+	return u
+}
+
+func (u *Updater[T]) UseIndex(indexes ...string) *Updater[T] {
+	u.indexHints = appendIndexHint(u.indexHints, hintUseIndex, indexes)
+	return u
+}
+
+func (u *Updater[T]) ForceIndex(indexes ...string) *Updater[T] {
+	u.indexHints = appendIndexHint(u.indexHints, hintForceIndex, indexes)
+	return u
+}
+
+func (u *Updater[T]) IgnoreIndex(indexes ...string) *Updater[T] {
+	u.indexHints = appendIndexHint(u.indexHints, hintIgnoreIndex, indexes)
+	return u
+}
+
+func (u *Updater[T]) Exec(ctx context.Context) (sql.Result, error) {
+	u.ctx = ctx
+	m, err := u.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	if m.hooks.beforeUpdate && u.val != nil {
+		if err = any(u.val).(BeforeUpdater).BeforeUpdate(ctx, u.sess); err != nil {
+			return nil, err
+		}
+	}
+	q, err := u.Build()
+	if err != nil {
+		return nil, err
+	}
+	return exec(ctx, u.sess, &QueryContext{
+		Type:    OpUpdate,
+		Builder: u,
+		Query:   q,
+		Model:   u.model,
+	})
+}
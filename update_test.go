@@ -0,0 +1,93 @@
+package Go_ORM
+
+import (
+	"Go_ORM/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUpdater_Build(t *testing.T) {
+	db, err := NewDB()
+	require.NoError(t, err)
+	sqliteDB, err := NewDB(DBWithDialect(SQLite))
+	require.NoError(t, err)
+	testCases := []struct {
+		name    string
+		builder QueryBuilder
+
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "no columns",
+			builder: NewUpdater[TestModel](db),
+			wantErr: errs.ErrNoUpdatedColumns,
+		},
+
+		{
+			name: "column",
+			builder: NewUpdater[TestModel](db).Update(&TestModel{
+				Age:       18,
+				FirstName: "Tom",
+			}).Set(C("Age"), C("FirstName")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?,`first_name`=?;",
+				Args: []any{int8(18), "Tom"},
+			},
+		},
+
+		{
+			name: "assignment and where",
+			builder: NewUpdater[TestModel](db).Update(&TestModel{
+				Age: 18,
+			}).Set(C("Age"), Assign("FirstName", "Jerry")).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?,`first_name`=? WHERE `id` = ?;",
+				Args: []any{int8(18), "Jerry", 1},
+			},
+		},
+
+		{
+			name:    "no entity",
+			builder: NewUpdater[TestModel](db).Set(C("Age")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?;",
+				Args: []any{int8(0)},
+			},
+		},
+
+		{
+			name:    "invalid column",
+			builder: NewUpdater[TestModel](db).Set(Assign("xxxx", 1)),
+			wantErr: errs.NewErrUnknownField("xxxx"),
+		},
+
+		{
+			name: "hints",
+			builder: NewUpdater[TestModel](db).Hints("MAX_EXECUTION_TIME(1000)").
+				ForceIndex("idx_age").Set(Assign("Age", 19)).Where(C("Age").Eq(18)),
+			wantQuery: &Query{
+				SQL:  "UPDATE /*+ MAX_EXECUTION_TIME(1000) */ `test_model` FORCE INDEX (`idx_age`) SET `age`=? WHERE `age` = ?;",
+				Args: []any{19, 18},
+			},
+		},
+
+		{
+			name:    "sqlite index hint",
+			builder: NewUpdater[TestModel](sqliteDB).IgnoreIndex("idx_age").Set(Assign("Age", 19)),
+			wantErr: errs.NewErrUnsupportedHint("sqlite"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, query)
+		})
+	}
+}